@@ -5,16 +5,23 @@ import (
 	"fmt"
 	"os"
 	"os/signal"
+	"path"
 	"regexp"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/BurntSushi/toml"
 	"github.com/SevereCloud/vksdk/v2/api"
+	"github.com/sklyar/vk-banhammer/internal/allowlist"
+	"github.com/sklyar/vk-banhammer/internal/audit"
 	"github.com/sklyar/vk-banhammer/internal/config"
 	"github.com/sklyar/vk-banhammer/internal/entity"
+	"github.com/sklyar/vk-banhammer/internal/langdetect"
+	"github.com/sklyar/vk-banhammer/internal/outbox"
 	"github.com/sklyar/vk-banhammer/internal/server"
 	"github.com/sklyar/vk-banhammer/internal/service"
+	"github.com/sklyar/vk-banhammer/internal/spampattern"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
@@ -23,6 +30,14 @@ import (
 var version = "unknown"
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "train" {
+		if err := runTrain(os.Args[2:]); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	cfg, err := config.ParseConfig()
 	if err != nil {
 		fmt.Println(err)
@@ -36,21 +51,65 @@ func main() {
 	}
 	defer logger.Sync() //nolint:errcheck
 
-	heuristicRules, err := loadHeuristicRules(cfg.HeuristicsPath)
+	heuristicRules, err := readHeuristicRules(cfg.HeuristicsPath)
 	if err != nil {
-		logger.Fatal("failed to load heuristic rules", zap.Error(err))
-	}
-	if err := validateHeuristicRules(heuristicRules); err != nil {
-		logger.Fatal("failed to validate heuristic rules", zap.Error(err))
+		logger.Fatal("failed to read heuristic rules", zap.Error(err))
 	}
 
 	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer cancel()
 
-	vkClient := api.NewVK(cfg.APIToken)
+	actionOutbox, err := outbox.New(cfg.OutboxPath)
+	if err != nil {
+		logger.Fatal("failed to open outbox", zap.Error(err))
+	}
+	defer actionOutbox.Close() //nolint:errcheck
+
+	trusted, err := allowlist.New(heuristicRules.Allowlist, cfg.MemberMinAge, cfg.MembersPath, cfg.TrustExistingMembers)
+	if err != nil {
+		logger.Fatal("failed to open allowlist", zap.Error(err))
+	}
+
+	auditLog, err := audit.New(cfg.AuditPath)
+	if err != nil {
+		logger.Fatal("failed to open audit log", zap.Error(err))
+	}
+	defer auditLog.Close() //nolint:errcheck
+
+	vkClient := service.NewVkClient(api.NewVK(cfg.APIToken))
+	foafClient := service.NewFoafClient(cfg.RegistrationDateTimeout)
+
+	banhammerService := service.NewService(logger, vkClient, foafClient, actionOutbox, trusted, auditLog, heuristicRules, service.Config{
+		GroupID:                  cfg.GroupID,
+		AllowlistRefreshInterval: cfg.AllowlistRefreshInterval,
+
+		CacheSize:        cfg.UserCacheSize,
+		CacheTTL:         cfg.UserCacheTTL,
+		NegativeCacheTTL: cfg.UserNegativeCacheTTL,
 
-	banhammerService := service.NewService(logger, vkClient, heuristicRules)
-	httpServer := server.NewServer(logger, cfg.HTTPAddr, banhammerService, cfg.CallbackConfirmationCode)
+		FloodWindows: cfg.FloodWindows,
+		Fingerprints: cfg.Fingerprints,
+		HistoryTTL:   cfg.HistoryTTL,
+
+		UsersGetTimeout:          cfg.UsersGetTimeout,
+		ResolveScreenNameTimeout: cfg.ResolveScreenNameTimeout,
+		GroupsGetByIDTimeout:     cfg.GroupsGetByIDTimeout,
+		GroupsGetMembersTimeout:  cfg.GroupsGetMembersTimeout,
+		GroupsBanTimeout:         cfg.GroupsBanTimeout,
+		WallDeleteCommentTimeout: cfg.WallDeleteCommentTimeout,
+		CheckLinkTimeout:         cfg.CheckLinkTimeout,
+
+		RetryInterval:    cfg.OutboxRetryInterval,
+		MaxRetryInterval: cfg.OutboxMaxRetryInterval,
+	})
+	if err := banhammerService.ResolveBlacklist(ctx); err != nil {
+		logger.Fatal("failed to resolve blacklist", zap.Error(err))
+	}
+	go banhammerService.RunOutboxWorker(ctx)
+	go banhammerService.RunAllowlistRefresher(ctx)
+	go reloadOnSignal(ctx, logger, cfg.HeuristicsPath, banhammerService)
+
+	httpServer := server.NewServer(logger, cfg.HTTPAddr, banhammerService, cfg.CallbackConfirmationCode, cfg.AdminToken)
 
 	if err := httpServer.ListenAndServe(ctx); err != nil {
 		logger.Fatal("failed to start server", zap.Error(err))
@@ -86,6 +145,52 @@ func newLogger(cfgLevel, version string) (*zap.Logger, error) {
 	return logger, nil
 }
 
+// readHeuristicRules loads and validates heuristic rules, then loads models of classifier rules.
+func readHeuristicRules(path string) (entity.HeuristicRules, error) {
+	rules, err := loadHeuristicRules(path)
+	if err != nil {
+		return entity.HeuristicRules{}, err
+	}
+	if err := validateHeuristicRules(rules); err != nil {
+		return entity.HeuristicRules{}, fmt.Errorf("failed to validate heuristic rules: %w", err)
+	}
+	if err := rules.LoadModels(); err != nil {
+		return entity.HeuristicRules{}, err
+	}
+	if err := rules.CompileExpressions(); err != nil {
+		return entity.HeuristicRules{}, err
+	}
+
+	return rules, nil
+}
+
+// reloadOnSignal reloads heuristic rules and classifier models on SIGHUP.
+// Current rules are kept if the new ones are invalid.
+func reloadOnSignal(ctx context.Context, logger *zap.Logger, path string, s *service.Service) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+		}
+
+		rules, err := readHeuristicRules(path)
+		if err != nil {
+			logger.Error("failed to reload heuristic rules", zap.Error(err))
+			continue
+		}
+		if err := s.ReloadRules(ctx, rules); err != nil {
+			logger.Error("failed to reload heuristic rules", zap.Error(err))
+			continue
+		}
+		logger.Info("heuristic rules reloaded")
+	}
+}
+
 func loadHeuristicRules(path string) (entity.HeuristicRules, error) {
 	var rules entity.HeuristicRules
 
@@ -96,25 +201,342 @@ func loadHeuristicRules(path string) (entity.HeuristicRules, error) {
 	return rules, nil
 }
 
+// attachmentTypes contains attachment types supported by attachment rules.
+var attachmentTypes = map[string]bool{
+	entity.AttachmentTypePhoto:   true,
+	entity.AttachmentTypeVideo:   true,
+	entity.AttachmentTypeAudio:   true,
+	entity.AttachmentTypeDoc:     true,
+	entity.AttachmentTypeLink:    true,
+	entity.AttachmentTypeSticker: true,
+}
+
 func validateHeuristicRules(rules entity.HeuristicRules) error {
-	if len(rules.PersonNonGrata) == 0 {
+	if len(rules.User) == 0 && len(rules.PersonNonGrata) == 0 && len(rules.Community) == 0 &&
+		len(rules.Mention) == 0 && len(rules.Flood) == 0 && len(rules.CopyPaste) == 0 &&
+		len(rules.Link) == 0 && len(rules.Attachment) == 0 && len(rules.Profanity) == 0 &&
+		len(rules.SpamPattern) == 0 && len(rules.Classifier) == 0 && len(rules.Language) == 0 &&
+		len(rules.Style) == 0 && len(rules.Expression) == 0 {
 		return fmt.Errorf("heuristic rules must contain at least one rule")
 	}
 
-	// birthday regexp without leading zeros in month and day. like "19.9.1921"
-	birthdateRegexp := regexp.MustCompile(`^([1-9]|[12]\d|3[01]).([1-9]|1[012]).\d{4}$`)
+	for _, rule := range rules.User {
+		if (rule.ID == nil) == (rule.ScreenName == nil) {
+			return fmt.Errorf("user rule must contain either id or screen name")
+		}
+		if rule.ID != nil && *rule.ID == 0 {
+			return fmt.Errorf("zero id in user rule")
+		}
+		if rule.ScreenName != nil && *rule.ScreenName == "" {
+			return fmt.Errorf("empty screen name in user rule")
+		}
+	}
+
+	// birthday regexp without leading zeros in month and day. like "19.9.1921" or "19.9" with hidden year.
+	birthdateRegexp := regexp.MustCompile(`^([1-9]|[12]\d|3[01])\.([1-9]|1[012])(\.\d{4})?$`)
 	for _, rule := range rules.PersonNonGrata {
 		if rule.Name != nil && *rule.Name == "" {
 			return fmt.Errorf("empty name in person non grata rule")
 		}
+		if rule.NameSimilarity != nil {
+			if rule.Name == nil {
+				return fmt.Errorf("name similarity requires name in person non grata rule")
+			}
+			if *rule.NameSimilarity <= 0 || *rule.NameSimilarity > 1 {
+				return fmt.Errorf("name similarity must be in (0, 1] in person non grata rule")
+			}
+		}
+		if !isValidPunishment(rule.FuzzyAction) {
+			return fmt.Errorf("invalid fuzzy action in person non grata rule")
+		}
 		if rule.BirthDate != nil {
 			if !birthdateRegexp.MatchString(*rule.BirthDate) {
 				return fmt.Errorf("invalid birthdate format in person non grata rule")
 			}
-			if _, err := time.Parse("2.1.2006", *rule.BirthDate); err != nil {
+			birthDate := *rule.BirthDate
+			if strings.Count(birthDate, ".") == 1 {
+				// Any leap year, so "29.2" is valid.
+				birthDate += ".2000"
+			}
+			if _, err := time.Parse("2.1.2006", birthDate); err != nil {
 				return fmt.Errorf("invalid birthdate format in person non grata rule")
 			}
 		}
+		if rule.MatchHiddenYear && (rule.BirthDate == nil || strings.Count(*rule.BirthDate, ".") != 2) {
+			return fmt.Errorf("match hidden year requires full birthdate in person non grata rule")
+		}
+		for _, bound := range []*int{rule.MinAge, rule.MaxAge, rule.MinBirthYear, rule.MaxBirthYear} {
+			if bound != nil && *bound < 0 {
+				return fmt.Errorf("negative age or birth year in person non grata rule")
+			}
+		}
+		if rule.MinAge != nil && rule.MaxAge != nil && *rule.MinAge > *rule.MaxAge {
+			return fmt.Errorf("min age is greater than max age in person non grata rule")
+		}
+		if rule.MinBirthYear != nil && rule.MaxBirthYear != nil && *rule.MinBirthYear > *rule.MaxBirthYear {
+			return fmt.Errorf("min birth year is greater than max birth year in person non grata rule")
+		}
+		if rule.Sex != nil && *rule.Sex != entity.SexFemale && *rule.Sex != entity.SexMale {
+			return fmt.Errorf("invalid sex in person non grata rule, expected %q or %q", entity.SexFemale, entity.SexMale)
+		}
+		if rule.Deactivated != nil && *rule.Deactivated != "deleted" && *rule.Deactivated != "banned" {
+			return fmt.Errorf("invalid deactivated in person non grata rule, expected \"deleted\" or \"banned\"")
+		}
+		for _, field := range []*string{
+			rule.MaidenName, rule.Nickname, rule.Domain, rule.City, rule.Country, rule.Status, rule.Site,
+		} {
+			if field != nil && *field == "" {
+				return fmt.Errorf("empty field in person non grata rule")
+			}
+		}
+		if rule.MinFollowersCount != nil && rule.MaxFollowersCount != nil && *rule.MinFollowersCount > *rule.MaxFollowersCount {
+			return fmt.Errorf("min followers count is greater than max followers count in person non grata rule")
+		}
+	}
+
+	for _, rule := range rules.Community {
+		if rule == (entity.HeuristicCommunityRule{}) {
+			return fmt.Errorf("empty community rule")
+		}
+		if rule.Name != nil && *rule.Name == "" {
+			return fmt.Errorf("empty name in community rule")
+		}
+		if rule.ScreenName != nil && *rule.ScreenName == "" {
+			return fmt.Errorf("empty screen name in community rule")
+		}
+		if rule.MinMembersCount != nil && rule.MaxMembersCount != nil && *rule.MinMembersCount > *rule.MaxMembersCount {
+			return fmt.Errorf("min members count is greater than max members count in community rule")
+		}
+	}
+
+	for _, rule := range rules.Flood {
+		if rule.MaxComments <= 0 {
+			return fmt.Errorf("max comments must be positive in flood rule")
+		}
+		if rule.Window <= 0 {
+			return fmt.Errorf("window must be positive in flood rule")
+		}
+		if !isValidPunishment(rule.Action) {
+			return fmt.Errorf("invalid action in flood rule")
+		}
+	}
+
+	for _, rule := range rules.CopyPaste {
+		if rule.MinAuthors < 2 {
+			return fmt.Errorf("min authors must be at least 2 in copy-paste rule")
+		}
+		if rule.Window <= 0 {
+			return fmt.Errorf("window must be positive in copy-paste rule")
+		}
+		if rule.MaxDistance != nil && (*rule.MaxDistance < 0 || *rule.MaxDistance > 64) {
+			return fmt.Errorf("max distance must be from 0 to 64 in copy-paste rule")
+		}
+		if rule.MinWords != nil && *rule.MinWords < 1 {
+			return fmt.Errorf("min words must be positive in copy-paste rule")
+		}
+		if !isValidPunishment(rule.Action) {
+			return fmt.Errorf("invalid action in copy-paste rule")
+		}
+	}
+
+	for _, domain := range rules.Shorteners {
+		if err := validateDomainPattern(domain); err != nil {
+			return fmt.Errorf("invalid shortener: %w", err)
+		}
+	}
+	for _, rule := range rules.Link {
+		for _, domains := range [][]string{rule.DenyDomains, rule.AllowDomains} {
+			for _, domain := range domains {
+				if err := validateDomainPattern(domain); err != nil {
+					return fmt.Errorf("invalid domain in link rule: %w", err)
+				}
+			}
+		}
+		if rule.MaxAccountAge < 0 {
+			return fmt.Errorf("max account age must be non-negative in link rule")
+		}
+		if !isValidPunishment(rule.Action) {
+			return fmt.Errorf("invalid action in link rule")
+		}
+	}
+
+	for _, rule := range rules.Attachment {
+		if rule.BlankText == nil && rule.MinCount == nil && len(rule.Types) == 0 && len(rule.LinkURLs) == 0 &&
+			len(rule.DocExtensions) == 0 && len(rule.StickerIDs) == 0 {
+			return fmt.Errorf("empty attachment rule")
+		}
+		for _, typ := range rule.Types {
+			if !attachmentTypes[typ] {
+				return fmt.Errorf("invalid type %q in attachment rule", typ)
+			}
+		}
+		if rule.MinCount != nil && *rule.MinCount < 1 {
+			return fmt.Errorf("min count must be positive in attachment rule")
+		}
+		if !isValidPunishment(rule.Action) {
+			return fmt.Errorf("invalid action in attachment rule")
+		}
+	}
+
+	for _, roots := range [][]string{rules.ProfanityRoots, rules.ProfanityExceptions} {
+		for _, root := range roots {
+			if strings.TrimSuffix(root, "*") == "" {
+				return fmt.Errorf("empty profanity root")
+			}
+		}
+	}
+	for _, rule := range rules.Profanity {
+		if rule.MinCount != nil && *rule.MinCount < 1 {
+			return fmt.Errorf("min count must be positive in profanity rule")
+		}
+		if rule.MaxMixedScriptWords != nil && *rule.MaxMixedScriptWords < 0 {
+			return fmt.Errorf("max mixed script words must be non-negative in profanity rule")
+		}
+		if !isValidPunishment(rule.Action) {
+			return fmt.Errorf("invalid action in profanity rule")
+		}
+	}
+
+	for _, rule := range rules.SpamPattern {
+		if len(rule.Kinds) == 0 {
+			return fmt.Errorf("empty kinds in spam pattern rule")
+		}
+		for _, kind := range rule.Kinds {
+			if !isValidSpamPatternKind(kind) {
+				return fmt.Errorf("invalid kind %q in spam pattern rule", kind)
+			}
+		}
+		if rule.MinCount != nil && *rule.MinCount < 1 {
+			return fmt.Errorf("min count must be positive in spam pattern rule")
+		}
+		if !isValidPunishment(rule.Action) {
+			return fmt.Errorf("invalid action in spam pattern rule")
+		}
+	}
+
+	for _, rule := range rules.Style {
+		// Length alone is not a style, it would match every long comment.
+		if rule.MinCapsRatio == nil && rule.MinEmojiRatio == nil && rule.MinRepeatRun == nil &&
+			rule.MinLinkDensity == nil && rule.MinMentionDensity == nil {
+			return fmt.Errorf("empty style rule")
+		}
+		for _, ratio := range []*float64{rule.MinCapsRatio, rule.MinEmojiRatio} {
+			if ratio != nil && (*ratio <= 0 || *ratio > 1) {
+				return fmt.Errorf("ratio must be from 0 to 1 in style rule")
+			}
+		}
+		for _, density := range []*float64{rule.MinLinkDensity, rule.MinMentionDensity} {
+			if density != nil && *density <= 0 {
+				return fmt.Errorf("density must be positive in style rule")
+			}
+		}
+		if (rule.MinLength != nil && *rule.MinLength < 1) || (rule.MinRepeatRun != nil && *rule.MinRepeatRun < 2) {
+			return fmt.Errorf("min length must be positive and min repeat run at least 2 in style rule")
+		}
+		if !isValidPunishment(rule.Action) {
+			return fmt.Errorf("invalid action in style rule")
+		}
+	}
+
+	for _, rule := range rules.Language {
+		if len(rule.Languages) == 0 && rule.MaxNonCyrillic == nil {
+			return fmt.Errorf("empty language rule")
+		}
+		for _, language := range rule.Languages {
+			if !isValidLanguage(language) {
+				return fmt.Errorf("invalid language %q in language rule", language)
+			}
+		}
+		if rule.MaxNonCyrillic != nil && (*rule.MaxNonCyrillic < 0 || *rule.MaxNonCyrillic >= 1) {
+			return fmt.Errorf("max non-cyrillic must be from 0 to 1 in language rule")
+		}
+		if rule.MinLetters != nil && *rule.MinLetters < 1 {
+			return fmt.Errorf("min letters must be positive in language rule")
+		}
+		if !isValidPunishment(rule.Action) {
+			return fmt.Errorf("invalid action in language rule")
+		}
+	}
+
+	for _, rule := range rules.Classifier {
+		if rule.Model == "" {
+			return fmt.Errorf("empty model in classifier rule")
+		}
+		if rule.Threshold <= 0 || rule.Threshold > 1 {
+			return fmt.Errorf("threshold must be from 0 to 1 in classifier rule")
+		}
+		if rule.MinTokens != nil && *rule.MinTokens < 1 {
+			return fmt.Errorf("min tokens must be positive in classifier rule")
+		}
+		if !isValidPunishment(rule.Action) {
+			return fmt.Errorf("invalid action in classifier rule")
+		}
+	}
+
+	for _, rule := range rules.Expression {
+		if strings.TrimSpace(rule.Expression) == "" {
+			return fmt.Errorf("empty expression rule")
+		}
+		if _, err := entity.CompileExpression(rule.Expression); err != nil {
+			return fmt.Errorf("invalid expression %q in expression rule: %w", rule.Expression, err)
+		}
+		if !isValidPunishment(rule.Action) {
+			return fmt.Errorf("invalid action in expression rule")
+		}
+	}
+
+	for _, rule := range rules.Mention {
+		if rule.MaxUsers == nil && rule.MaxCommunities == nil && len(rule.Communities) == 0 && len(rule.ScreenNames) == 0 {
+			return fmt.Errorf("empty mention rule")
+		}
+		if (rule.MaxUsers != nil && *rule.MaxUsers < 0) || (rule.MaxCommunities != nil && *rule.MaxCommunities < 0) {
+			return fmt.Errorf("negative max mentions in mention rule")
+		}
+	}
+
+	return nil
+}
+
+// isValidPunishment checks punishment of a rule, empty punishment means the default one.
+func isValidPunishment(p entity.Punishment) bool {
+	switch p {
+	case "", entity.PunishmentBan, entity.PunishmentDelete, entity.PunishmentNone:
+		return true
+	default:
+		return false
+	}
+}
+
+func isValidSpamPatternKind(kind string) bool {
+	for _, k := range spampattern.Kinds {
+		if string(k) == kind {
+			return true
+		}
+	}
+
+	return false
+}
+
+func isValidLanguage(language string) bool {
+	for _, l := range langdetect.Languages {
+		if l == language {
+			return true
+		}
+	}
+
+	return false
+}
+
+// validateDomainPattern checks domain pattern used in link rules.
+func validateDomainPattern(pattern string) error {
+	if pattern == "" {
+		return fmt.Errorf("empty domain")
+	}
+	if strings.ContainsAny(pattern, "/: ") {
+		return fmt.Errorf("domain %q must not contain scheme or path", pattern)
+	}
+	if _, err := path.Match(pattern, ""); err != nil {
+		return fmt.Errorf("domain %q: %w", pattern, err)
 	}
 
 	return nil
@@ -2,6 +2,7 @@ package main
 
 import (
 	"testing"
+	"time"
 
 	"github.com/sklyar/vk-banhammer/internal/entity"
 )
@@ -32,6 +33,78 @@ func TestValidateHeuristicRules(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "valid person non grata rule with birthday without year",
+			rules: entity.HeuristicRules{
+				PersonNonGrata: []entity.HeuristicPersonNonGrataRule{
+					{BirthDate: toPtr("29.2")},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "valid person non grata rule with age range",
+			rules: entity.HeuristicRules{
+				PersonNonGrata: []entity.HeuristicPersonNonGrataRule{
+					{MaxAge: toPtr(13), MinBirthYear: toPtr(2010)},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalid format birthday without year",
+			rules: entity.HeuristicRules{
+				PersonNonGrata: []entity.HeuristicPersonNonGrataRule{
+					{BirthDate: toPtr("31.13")},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "match hidden year without full birthday",
+			rules: entity.HeuristicRules{
+				PersonNonGrata: []entity.HeuristicPersonNonGrataRule{
+					{BirthDate: toPtr("1.11"), MatchHiddenYear: true},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid age range",
+			rules: entity.HeuristicRules{
+				PersonNonGrata: []entity.HeuristicPersonNonGrataRule{
+					{MinAge: toPtr(18), MaxAge: toPtr(14)},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "valid person non grata rule with fuzzy name",
+			rules: entity.HeuristicRules{
+				PersonNonGrata: []entity.HeuristicPersonNonGrataRule{
+					{Name: toPtr("test"), NameSimilarity: toPtr(0.9), FuzzyAction: entity.PunishmentDelete},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalid name similarity",
+			rules: entity.HeuristicRules{
+				PersonNonGrata: []entity.HeuristicPersonNonGrataRule{
+					{Name: toPtr("test"), NameSimilarity: toPtr(1.5)},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid fuzzy action",
+			rules: entity.HeuristicRules{
+				PersonNonGrata: []entity.HeuristicPersonNonGrataRule{
+					{Name: toPtr("test"), NameSimilarity: toPtr(0.9), FuzzyAction: "kick"},
+				},
+			},
+			wantErr: true,
+		},
 		{
 			name: "invalid format birthday",
 			rules: entity.HeuristicRules{
@@ -50,6 +123,357 @@ func TestValidateHeuristicRules(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "valid person non grata rule with profile fields",
+			rules: entity.HeuristicRules{
+				PersonNonGrata: []entity.HeuristicPersonNonGrataRule{
+					{City: toPtr("Moscow"), Sex: toPtr("male"), MaxFollowersCount: toPtr(10)},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalid sex",
+			rules: entity.HeuristicRules{
+				PersonNonGrata: []entity.HeuristicPersonNonGrataRule{
+					{Sex: toPtr("2")},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid deactivated",
+			rules: entity.HeuristicRules{
+				PersonNonGrata: []entity.HeuristicPersonNonGrataRule{
+					{Deactivated: toPtr("yes")},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "empty status",
+			rules: entity.HeuristicRules{
+				PersonNonGrata: []entity.HeuristicPersonNonGrataRule{
+					{Status: toPtr("")},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "valid community rule",
+			rules: entity.HeuristicRules{
+				Community: []entity.HeuristicCommunityRule{
+					{ScreenName: toPtr("spam_club"), Verified: toPtr(false)},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "empty community rule",
+			rules: entity.HeuristicRules{
+				Community: []entity.HeuristicCommunityRule{{}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid members count range in community rule",
+			rules: entity.HeuristicRules{
+				Community: []entity.HeuristicCommunityRule{
+					{MinMembersCount: toPtr(100), MaxMembersCount: toPtr(10)},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "valid mention rule",
+			rules: entity.HeuristicRules{
+				Mention: []entity.HeuristicMentionRule{
+					{MaxUsers: toPtr(3)},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "empty mention rule",
+			rules: entity.HeuristicRules{
+				Mention: []entity.HeuristicMentionRule{{}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "valid user rules",
+			rules: entity.HeuristicRules{
+				User: []entity.HeuristicUserRule{
+					{ID: toPtr(1)},
+					{ScreenName: toPtr("durov")},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "user rule with id and screen name",
+			rules: entity.HeuristicRules{
+				User: []entity.HeuristicUserRule{
+					{ID: toPtr(1), ScreenName: toPtr("durov")},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "valid flood rule",
+			rules: entity.HeuristicRules{
+				Flood: []entity.HeuristicFloodRule{
+					{MaxComments: 5, Window: time.Minute, PerPost: true},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "flood rule without window",
+			rules: entity.HeuristicRules{
+				Flood: []entity.HeuristicFloodRule{
+					{MaxComments: 5},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid action in flood rule",
+			rules: entity.HeuristicRules{
+				Flood: []entity.HeuristicFloodRule{
+					{MaxComments: 5, Window: time.Minute, Action: "kick"},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "valid copy-paste rule",
+			rules: entity.HeuristicRules{
+				CopyPaste: []entity.HeuristicCopyPasteRule{
+					{MinAuthors: 3, Window: 10 * time.Minute, MaxDistance: toPtr(5), Cleanup: true},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "single author in copy-paste rule",
+			rules: entity.HeuristicRules{
+				CopyPaste: []entity.HeuristicCopyPasteRule{
+					{MinAuthors: 1, Window: 10 * time.Minute},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid max distance in copy-paste rule",
+			rules: entity.HeuristicRules{
+				CopyPaste: []entity.HeuristicCopyPasteRule{
+					{MinAuthors: 3, Window: 10 * time.Minute, MaxDistance: toPtr(65)},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "valid link rule",
+			rules: entity.HeuristicRules{
+				Shorteners: []string{"vk.cc", "clck.ru"},
+				Link: []entity.HeuristicLinkRule{
+					{DenyDomains: []string{"*.scam.ru"}, AllowDomains: []string{"vk.com"}, MaxAccountAge: time.Hour},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "link rule with url instead of domain",
+			rules: entity.HeuristicRules{
+				Link: []entity.HeuristicLinkRule{
+					{DenyDomains: []string{"https://scam.ru"}},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid domain pattern in link rule",
+			rules: entity.HeuristicRules{
+				Link: []entity.HeuristicLinkRule{
+					{DenyDomains: []string{"[scam.ru"}},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "valid attachment rule",
+			rules: entity.HeuristicRules{
+				Attachment: []entity.HeuristicAttachmentRule{
+					{Types: []string{"sticker"}, BlankText: toPtr(true), Action: entity.PunishmentDelete},
+					{DocExtensions: []string{"apk"}},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "empty attachment rule",
+			rules: entity.HeuristicRules{
+				Attachment: []entity.HeuristicAttachmentRule{{}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid type in attachment rule",
+			rules: entity.HeuristicRules{
+				Attachment: []entity.HeuristicAttachmentRule{
+					{Types: []string{"gif"}},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "valid profanity rule",
+			rules: entity.HeuristicRules{
+				Profanity:           []entity.HeuristicProfanityRule{{Obfuscated: toPtr(true)}},
+				ProfanityExceptions: []string{"бляха"},
+			},
+			wantErr: false,
+		},
+		{
+			name: "empty profanity root",
+			rules: entity.HeuristicRules{
+				Profanity:      []entity.HeuristicProfanityRule{{}},
+				ProfanityRoots: []string{"*"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid min count in profanity rule",
+			rules: entity.HeuristicRules{
+				Profanity: []entity.HeuristicProfanityRule{{MinCount: toPtr(0)}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "valid spam pattern rule",
+			rules: entity.HeuristicRules{
+				SpamPattern: []entity.HeuristicSpamPatternRule{
+					{Kinds: []string{"phone", "telegram", "whatsapp", "wallet", "card"}},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "spam pattern rule without kinds",
+			rules: entity.HeuristicRules{
+				SpamPattern: []entity.HeuristicSpamPatternRule{{MinCount: toPtr(2)}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid kind in spam pattern rule",
+			rules: entity.HeuristicRules{
+				SpamPattern: []entity.HeuristicSpamPatternRule{{Kinds: []string{"email"}}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "valid classifier rule",
+			rules: entity.HeuristicRules{
+				Classifier: []entity.HeuristicClassifierRule{
+					{Model: "model.json", Threshold: 0.95, MinTokens: toPtr(5)},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "classifier rule without model",
+			rules: entity.HeuristicRules{
+				Classifier: []entity.HeuristicClassifierRule{{Threshold: 0.95}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid threshold in classifier rule",
+			rules: entity.HeuristicRules{
+				Classifier: []entity.HeuristicClassifierRule{{Model: "model.json", Threshold: 95}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "valid language rule",
+			rules: entity.HeuristicRules{
+				Language: []entity.HeuristicLanguageRule{
+					{Languages: []string{"ru", "uk"}, MinLetters: toPtr(30)},
+					{MaxNonCyrillic: toPtr(0.5)},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "empty language rule",
+			rules: entity.HeuristicRules{
+				Language: []entity.HeuristicLanguageRule{{MinLetters: toPtr(30)}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid language in language rule",
+			rules: entity.HeuristicRules{
+				Language: []entity.HeuristicLanguageRule{{Languages: []string{"russian"}}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "valid style rule",
+			rules: entity.HeuristicRules{
+				Style: []entity.HeuristicStyleRule{
+					{MinCapsRatio: toPtr(0.7), MinLength: toPtr(31), Action: entity.PunishmentDelete},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "style rule with length only",
+			rules: entity.HeuristicRules{
+				Style: []entity.HeuristicStyleRule{{MinLength: toPtr(31)}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid ratio in style rule",
+			rules: entity.HeuristicRules{
+				Style: []entity.HeuristicStyleRule{{MinEmojiRatio: toPtr(50.0)}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "valid expression rule",
+			rules: entity.HeuristicRules{
+				Expression: []entity.HeuristicExpressionRule{{
+					Expression: `(user.name == "Иван Иванов" or user.birth_date == "1.11.2000") and len(comment.links) > 0`,
+					Action:     entity.PunishmentDelete,
+				}},
+			},
+			wantErr: false,
+		},
+		{
+			name: "empty expression rule",
+			rules: entity.HeuristicRules{
+				Expression: []entity.HeuristicExpressionRule{{Expression: " "}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "expression rule with unknown variable",
+			rules: entity.HeuristicRules{
+				Expression: []entity.HeuristicExpressionRule{{Expression: `user.nickname == "spammer"`}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "expression rule with type error",
+			rules: entity.HeuristicRules{
+				Expression: []entity.HeuristicExpressionRule{{Expression: `comment.length > "10"`}},
+			},
+			wantErr: true,
+		},
 		{
 			name:    "empty rules",
 			rules:   entity.HeuristicRules{},
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/sklyar/vk-banhammer/internal/bayes"
+	"github.com/sklyar/vk-banhammer/internal/config"
+	"github.com/sklyar/vk-banhammer/internal/textnorm"
+)
+
+// maxLineSize is a maximum size of a line with labeled comment.
+const maxLineSize = 1024 * 1024
+
+// labeledComment is a line of the train command input.
+type labeledComment struct {
+	Text string `json:"text"`
+	Spam bool   `json:"spam"`
+}
+
+// runTrain trains a model for classifier rules from labeled comments.
+func runTrain(args []string) error {
+	cfg, err := config.ParseTrainConfig(args)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Open(cfg.InputPath)
+	if err != nil {
+		return fmt.Errorf("failed to open labeled comments: %w", err)
+	}
+	defer f.Close() //nolint:errcheck
+
+	model, err := train(f)
+	if err != nil {
+		return err
+	}
+	model.Prune(cfg.MinCount)
+
+	if err := model.Save(cfg.OutputPath); err != nil {
+		return fmt.Errorf("failed to save model: %w", err)
+	}
+	fmt.Printf(
+		"trained on %d spam and %d ham comments, %d tokens\n",
+		model.Docs[bayes.Spam], model.Docs[bayes.Ham], len(model.Counts),
+	)
+
+	return nil
+}
+
+func train(r io.Reader) (*bayes.Model, error) {
+	model := bayes.New()
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+
+		var c labeledComment
+		if err := json.Unmarshal(scanner.Bytes(), &c); err != nil {
+			return nil, fmt.Errorf("failed to decode line %d: %w", line, err)
+		}
+
+		class := bayes.Ham
+		if c.Spam {
+			class = bayes.Spam
+		}
+		model.Add(bayes.Tokens(textnorm.Normalize(c.Text)), class)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read labeled comments: %w", err)
+	}
+
+	if model.Docs[bayes.Ham] == 0 || model.Docs[bayes.Spam] == 0 {
+		return nil, fmt.Errorf("failed to train: %w", bayes.ErrEmptyClass)
+	}
+
+	return model, nil
+}
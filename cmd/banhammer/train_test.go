@@ -0,0 +1,60 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/sklyar/vk-banhammer/internal/bayes"
+)
+
+func TestTrain(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		input    string
+		wantDocs [2]int
+		wantErr  bool
+	}{
+		{
+			name: "labeled comments",
+			input: `{"text": "Заработок без вложений, пиши в личку", "spam": true}
+
+{"text": "Спасибо за статью", "spam": false}
+{"text": "Интересно, когда продолжение?"}
+`,
+			wantDocs: [2]int{2, 1},
+		},
+		{
+			name:    "invalid line",
+			input:   `{"text": "Спасибо за статью"}` + "\n" + `"spam"`,
+			wantErr: true,
+		},
+		{
+			name:    "no spam",
+			input:   `{"text": "Спасибо за статью"}`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			model, err := train(strings.NewReader(tt.input))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("train() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if model.Docs != tt.wantDocs {
+				t.Errorf("train() docs = %v, want %v", model.Docs, tt.wantDocs)
+			}
+			if model.Counts["заработок"][bayes.Spam] != 1 {
+				t.Errorf("train() counts = %v, want %q in spam", model.Counts, "заработок")
+			}
+		})
+	}
+}
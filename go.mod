@@ -6,9 +6,10 @@ require (
 	github.com/BurntSushi/toml v1.2.1
 	github.com/SevereCloud/vksdk/v2 v2.15.0
 	github.com/golang/mock v1.6.0
-	github.com/hashicorp/golang-lru/v2 v2.0.1
+	github.com/hashicorp/golang-lru/v2 v2.0.7
 	github.com/jessevdk/go-flags v1.5.0
 	go.uber.org/zap v1.24.0
+	golang.org/x/text v0.3.7
 )
 
 require (
@@ -18,5 +19,4 @@ require (
 	go.uber.org/atomic v1.7.0 // indirect
 	go.uber.org/multierr v1.6.0 // indirect
 	golang.org/x/sys v0.0.0-20210510120138-977fb7262007 // indirect
-	golang.org/x/text v0.3.7 // indirect
 )
@@ -0,0 +1,143 @@
+package allowlist
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/sklyar/vk-banhammer/internal/jsonfile"
+)
+
+// Allowlist keeps trusted users which are never banned.
+// Users are trusted if they are listed explicitly, manage the community
+// or have been its members for at least memberMinAge.
+type Allowlist struct {
+	// ids are explicitly trusted users, they are replaced on rules reload.
+	ids map[int]struct{}
+
+	// memberMinAge is a membership duration after which members are trusted.
+	// Members are not trusted if it is zero.
+	memberMinAge time.Duration
+	// path is a file keeping the time members were seen first.
+	// Membership is kept in memory only if it is empty.
+	path string
+	// trustExisting makes members found by the first sync trusted right away.
+	trustExisting bool
+
+	managers    map[int]struct{}
+	memberSince map[int]time.Time
+	// synced is set once members are loaded from the file or set for the first time.
+	synced bool
+	m      sync.RWMutex
+}
+
+// New creates a new allowlist and loads membership stored in the file.
+// Members found by the first sync are considered joined at that time,
+// unless trustExisting is set, then they are considered old members.
+func New(ids []int, memberMinAge time.Duration, path string, trustExisting bool) (*Allowlist, error) {
+	a := &Allowlist{
+		ids:           toSet(ids),
+		memberMinAge:  memberMinAge,
+		path:          path,
+		trustExisting: trustExisting,
+		managers:      make(map[int]struct{}),
+		memberSince:   make(map[int]time.Time),
+	}
+
+	if path != "" {
+		exists, err := jsonfile.Load(path, &a.memberSince)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load members: %w", err)
+		}
+		a.synced = exists
+	}
+
+	return a, nil
+}
+
+// Contains checks if user is trusted at the given time.
+func (a *Allowlist) Contains(userID int, now time.Time) bool {
+	a.m.RLock()
+	defer a.m.RUnlock()
+
+	if _, exists := a.ids[userID]; exists {
+		return true
+	}
+	if _, exists := a.managers[userID]; exists {
+		return true
+	}
+	if a.memberMinAge <= 0 {
+		return false
+	}
+	since, exists := a.memberSince[userID]
+
+	return exists && now.Sub(since) >= a.memberMinAge
+}
+
+// TracksMembers reports whether membership duration is used.
+func (a *Allowlist) TracksMembers() bool {
+	return a.memberMinAge > 0
+}
+
+// SetTrusted replaces explicitly trusted users.
+func (a *Allowlist) SetTrusted(ids []int) {
+	trusted := toSet(ids)
+
+	a.m.Lock()
+	a.ids = trusted
+	a.m.Unlock()
+}
+
+// SetManagers replaces community managers.
+func (a *Allowlist) SetManagers(ids []int) {
+	managers := toSet(ids)
+
+	a.m.Lock()
+	a.managers = managers
+	a.m.Unlock()
+}
+
+// SetMembers replaces community members.
+// Members seen for the first time are considered joined at the given time,
+// members who left are forgotten. On the first sync there is no join time yet,
+// existing members are considered old ones only if they are trusted explicitly.
+// The file is rewritten only if membership has changed.
+func (a *Allowlist) SetMembers(ids []int, now time.Time) error {
+	a.m.Lock()
+	defer a.m.Unlock()
+
+	joinedAt := now
+	if !a.synced && a.trustExisting {
+		joinedAt = time.Time{}
+	}
+
+	changed := len(ids) != len(a.memberSince)
+	memberSince := make(map[int]time.Time, len(ids))
+	for _, id := range ids {
+		since, exists := a.memberSince[id]
+		if !exists {
+			since = joinedAt
+			changed = true
+		}
+		memberSince[id] = since
+	}
+	a.memberSince = memberSince
+
+	wasSynced := a.synced
+	a.synced = true
+
+	if a.path == "" || (wasSynced && !changed) {
+		return nil
+	}
+
+	return jsonfile.Save(a.path, memberSince)
+}
+
+func toSet(ids []int) map[int]struct{} {
+	set := make(map[int]struct{}, len(ids))
+	for _, id := range ids {
+		set[id] = struct{}{}
+	}
+
+	return set
+}
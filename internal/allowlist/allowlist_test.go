@@ -0,0 +1,119 @@
+package allowlist
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestAllowlistContains(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	path := filepath.Join(t.TempDir(), "members.json")
+
+	a, err := New([]int{1}, 30*24*time.Hour, path, false)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	a.SetManagers([]int{2})
+	if err := a.SetMembers([]int{3, 4}, now); err != nil {
+		t.Fatalf("SetMembers() error = %v", err)
+	}
+	// Member 3 stays, member 5 joins later.
+	if err := a.SetMembers([]int{3, 5}, now.Add(20*24*time.Hour)); err != nil {
+		t.Fatalf("SetMembers() error = %v", err)
+	}
+
+	// Membership survives restart.
+	a, err = New([]int{1}, 30*24*time.Hour, path, false)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	a.SetManagers([]int{2})
+
+	checkAt := now.Add(31 * 24 * time.Hour)
+	tests := []struct {
+		name   string
+		userID int
+		want   bool
+	}{
+		{name: "explicit id", userID: 1, want: true},
+		{name: "manager", userID: 2, want: true},
+		{name: "old member", userID: 3, want: true},
+		{name: "left member", userID: 4, want: false},
+		{name: "new member", userID: 5, want: false},
+		{name: "stranger", userID: 6, want: false},
+	}
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := a.Contains(tt.userID, checkAt); got != tt.want {
+				t.Errorf("Contains() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAllowlistSetMembers_FirstSync(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name          string
+		trustExisting bool
+		want          map[int]bool
+	}{
+		{
+			name: "existing members wait",
+			want: map[int]bool{3: false, 5: false, 7: false},
+		},
+		{
+			name:          "existing members trusted",
+			trustExisting: true,
+			want:          map[int]bool{3: true, 5: false, 7: false},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			path := filepath.Join(t.TempDir(), "members.json")
+			a, err := New(nil, 30*24*time.Hour, path, tt.trustExisting)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			if err := a.SetMembers([]int{3}, now); err != nil {
+				t.Fatalf("SetMembers() error = %v", err)
+			}
+			if err := a.SetMembers([]int{3, 5}, now); err != nil {
+				t.Fatalf("SetMembers() error = %v", err)
+			}
+
+			// The first sync is not repeated after restart.
+			a, err = New(nil, 30*24*time.Hour, path, tt.trustExisting)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			if err := a.SetMembers([]int{3, 5, 7}, now); err != nil {
+				t.Fatalf("SetMembers() error = %v", err)
+			}
+
+			for userID, want := range tt.want {
+				if got := a.Contains(userID, now); got != want {
+					t.Errorf("Contains(%d) = %v, want %v", userID, got, want)
+				}
+			}
+			// Members of the first sync are trusted after the membership duration.
+			if got := a.Contains(3, now.Add(31*24*time.Hour)); !got {
+				t.Errorf("Contains(%d) = %v, want %v", 3, got, true)
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/sklyar/vk-banhammer/internal/entity"
+)
+
+// Log appends decisions to a file as JSON lines, so moderation can be reviewed later.
+// If path is empty, decisions are not recorded.
+type Log struct {
+	f *os.File
+	m sync.Mutex
+}
+
+// record is a line of the audit log.
+type record struct {
+	Time time.Time `json:"time"`
+	entity.Decision
+}
+
+// New opens audit log, the file is created if it does not exist.
+func New(path string) (*Log, error) {
+	if path == "" {
+		return &Log{}, nil
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+
+	return &Log{f: f}, nil
+}
+
+// Record appends decision to the log.
+func (l *Log) Record(d entity.Decision) error {
+	if l.f == nil {
+		return nil
+	}
+
+	data, err := json.Marshal(record{Time: time.Now(), Decision: d})
+	if err != nil {
+		return fmt.Errorf("failed to marshal decision: %w", err)
+	}
+
+	l.m.Lock()
+	defer l.m.Unlock()
+
+	if _, err := l.f.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+
+	return nil
+}
+
+// Close closes the log file.
+func (l *Log) Close() error {
+	if l.f == nil {
+		return nil
+	}
+
+	return l.f.Close()
+}
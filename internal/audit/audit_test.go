@@ -0,0 +1,72 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sklyar/vk-banhammer/internal/entity"
+)
+
+func TestLog_Record(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+
+	// Records are appended after reopening.
+	for _, id := range []int{1, 2} {
+		l, err := New(path)
+		if err != nil {
+			t.Fatalf("New() error = %v", err)
+		}
+		d := entity.Decision{CommentID: id, FromID: 87524863, Reason: entity.BanReasonFlood}
+		if err := l.Record(d); err != nil {
+			t.Fatalf("Record() error = %v", err)
+		}
+		if err := l.Close(); err != nil {
+			t.Fatalf("Close() error = %v", err)
+		}
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer f.Close()
+
+	var got []record
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var r record
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			t.Fatalf("Unmarshal() error = %v", err)
+		}
+		got = append(got, r)
+	}
+
+	if len(got) != 2 {
+		t.Fatalf("got %d records, want 2", len(got))
+	}
+	for i, r := range got {
+		if r.CommentID != i+1 || r.Reason != entity.BanReasonFlood || r.Time.IsZero() {
+			t.Errorf("record %d = %+v", i, r)
+		}
+	}
+}
+
+func TestLog_Disabled(t *testing.T) {
+	t.Parallel()
+
+	l, err := New("")
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if err := l.Record(entity.Decision{}); err != nil {
+		t.Errorf("Record() error = %v", err)
+	}
+	if err := l.Close(); err != nil {
+		t.Errorf("Close() error = %v", err)
+	}
+}
@@ -0,0 +1,126 @@
+package bayes
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/sklyar/vk-banhammer/internal/jsonfile"
+	"github.com/sklyar/vk-banhammer/internal/profanity"
+)
+
+// Class is a class of a document.
+type Class int
+
+// Available classes.
+const (
+	Ham Class = iota
+	Spam
+)
+
+// ErrEmptyClass is returned when the model has no documents of a class.
+var ErrEmptyClass = errors.New("no documents of a class")
+
+// Model is a multinomial naive Bayes model with Laplace smoothing.
+// It is trained and saved by "banhammer train" and loaded by classifier rules.
+type Model struct {
+	// Docs is a number of training documents of each class.
+	Docs [2]int `json:"docs"`
+	// Tokens is a total number of tokens of each class.
+	Tokens [2]int `json:"tokens"`
+	// Counts is a number of occurrences of each token in each class.
+	Counts map[string][2]int `json:"counts"`
+}
+
+// New creates an empty model.
+func New() *Model {
+	return &Model{Counts: make(map[string][2]int)}
+}
+
+// Load reads model from JSON file.
+func Load(path string) (*Model, error) {
+	m := New()
+	ok, err := jsonfile.Load(path, m)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, fmt.Errorf("model %s does not exist", path)
+	}
+	if m.Docs[Ham] == 0 || m.Docs[Spam] == 0 {
+		return nil, fmt.Errorf("invalid model %s: %w", path, ErrEmptyClass)
+	}
+
+	return m, nil
+}
+
+// Save writes model to JSON file.
+func (m *Model) Save(path string) error {
+	return jsonfile.Save(path, m)
+}
+
+// Add adds tokens of a training document of the class.
+func (m *Model) Add(tokens []string, class Class) {
+	m.Docs[class]++
+	m.Tokens[class] += len(tokens)
+	for _, t := range tokens {
+		counts := m.Counts[t]
+		counts[class]++
+		m.Counts[t] = counts
+	}
+}
+
+// Prune removes tokens seen less than minCount times in all classes, they add noise and bloat the model.
+func (m *Model) Prune(minCount int) {
+	for t, counts := range m.Counts {
+		if counts[Ham]+counts[Spam] >= minCount {
+			continue
+		}
+		m.Tokens[Ham] -= counts[Ham]
+		m.Tokens[Spam] -= counts[Spam]
+		delete(m.Counts, t)
+	}
+}
+
+// SpamProbability returns probability of tokens to be spam, from 0 to 1,
+// and a number of tokens known to the model. Unknown tokens are ignored.
+func (m *Model) SpamProbability(tokens []string) (float64, int) {
+	vocabulary := float64(len(m.Counts))
+	total := m.Docs[Ham] + m.Docs[Spam]
+
+	// Log-odds of spam against ham.
+	odds := math.Log(float64(m.Docs[Spam])/float64(total)) - math.Log(float64(m.Docs[Ham])/float64(total))
+	known := 0
+	for _, t := range tokens {
+		counts, ok := m.Counts[t]
+		if !ok {
+			continue
+		}
+		known++
+		odds += math.Log((float64(counts[Spam]) + 1) / (float64(m.Tokens[Spam]) + vocabulary))
+		odds -= math.Log((float64(counts[Ham]) + 1) / (float64(m.Tokens[Ham]) + vocabulary))
+	}
+
+	return 1 / (1 + math.Exp(-odds)), known
+}
+
+// Tokens splits text normalized with textnorm into tokens.
+// Words are stemmed, so inflected forms share counts, and single letters are dropped.
+func Tokens(normalized string) []string {
+	words := strings.FieldsFunc(normalized, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	tokens := words[:0]
+	for _, w := range words {
+		if utf8.RuneCountInString(w) < 2 {
+			continue
+		}
+		tokens = append(tokens, profanity.Stem(w))
+	}
+
+	return tokens
+}
@@ -0,0 +1,139 @@
+package bayes
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/sklyar/vk-banhammer/internal/textnorm"
+)
+
+func trainModel() *Model {
+	m := New()
+	for _, text := range []string{
+		"Заработок от 5000 рублей в день без вложений, пиши в личку",
+		"Легкий заработок в интернете, подробности в личных сообщениях",
+		"Пассивный доход без вложений, пиши мне в личку",
+	} {
+		m.Add(Tokens(textnorm.Normalize(text)), Spam)
+	}
+	for _, text := range []string{
+		"Спасибо за статью, было очень интересно читать",
+		"Отличные фотографии, особенно вечерний город",
+		"Интересно, а когда будет продолжение истории?",
+	} {
+		m.Add(Tokens(textnorm.Normalize(text)), Ham)
+	}
+
+	return m
+}
+
+func TestModelSpamProbability(t *testing.T) {
+	t.Parallel()
+
+	m := trainModel()
+
+	tests := []struct {
+		name      string
+		text      string
+		wantSpam  bool
+		wantKnown int
+	}{
+		{
+			name:      "spam",
+			text:      "Заработок без вложений, пишите в личку",
+			wantSpam:  true,
+			wantKnown: 5,
+		},
+		{
+			name:      "ham",
+			text:      "Очень интересная статья, спасибо",
+			wantSpam:  false,
+			wantKnown: 4,
+		},
+		{
+			name:      "unknown tokens",
+			text:      "Погода сегодня хорошая",
+			wantSpam:  false,
+			wantKnown: 0,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			p, known := m.SpamProbability(Tokens(textnorm.Normalize(tt.text)))
+			if (p > 0.5) != tt.wantSpam {
+				t.Errorf("SpamProbability() = %v, want spam %v", p, tt.wantSpam)
+			}
+			if known != tt.wantKnown {
+				t.Errorf("SpamProbability() known = %v, want %v", known, tt.wantKnown)
+			}
+		})
+	}
+}
+
+func TestModelPrune(t *testing.T) {
+	t.Parallel()
+
+	m := New()
+	m.Add([]string{"заработок", "личк", "заработок"}, Spam)
+	m.Add([]string{"стат"}, Ham)
+	m.Prune(2)
+
+	if len(m.Counts) != 1 {
+		t.Errorf("Prune() counts = %v, want only %q", m.Counts, "заработок")
+	}
+	if m.Tokens != [2]int{0, 2} {
+		t.Errorf("Prune() tokens = %v, want %v", m.Tokens, [2]int{0, 2})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "model.json")
+	m := trainModel()
+	if err := m.Save(path); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if loaded.Docs != m.Docs || len(loaded.Counts) != len(m.Counts) {
+		t.Errorf("Load() = %+v, want %+v", loaded, m)
+	}
+
+	empty := New()
+	empty.Add([]string{"заработок"}, Spam)
+	if err := empty.Save(path); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	if _, err := Load(path); !errors.Is(err, ErrEmptyClass) {
+		t.Errorf("Load() error = %v, want %v", err, ErrEmptyClass)
+	}
+
+	if _, err := Load(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Errorf("Load() error = %v, wantErr %v", err, true)
+	}
+}
+
+func TestTokens(t *testing.T) {
+	t.Parallel()
+
+	got := Tokens(textnorm.Normalize("Заработок в интернете!!! 5000 р/день"))
+	want := []string{"заработок", "интернет", "5000", "ден"}
+	if len(got) != len(want) {
+		t.Fatalf("Tokens() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Tokens() = %v, want %v", got, want)
+			break
+		}
+	}
+}
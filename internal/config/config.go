@@ -2,6 +2,7 @@ package config
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/jessevdk/go-flags"
 )
@@ -14,6 +15,36 @@ type Config struct {
 	APIToken                 string `long:"api-token:" env:"API_TOKEN" description:"VK API token" required:"true"`
 	CallbackConfirmationCode string `long:"callback-confirmation-code" env:"CALLBACK_CONFIRMATION_CODE" description:"Callback confirmation code from VK" required:"true"`
 	HTTPAddr                 string `long:"http-addr" env:"HTTP_ADDR" description:"HTTP server address" default:":8080"`
+	AdminToken               string `long:"admin-token" env:"ADMIN_TOKEN" description:"Token for admin endpoints, admin endpoints are disabled if empty"`
+
+	UserCacheSize        int           `long:"user-cache-size" env:"USER_CACHE_SIZE" description:"Maximum number of cached users" default:"1000"`
+	UserCacheTTL         time.Duration `long:"user-cache-ttl" env:"USER_CACHE_TTL" description:"Lifetime of a cached user" default:"10m"`
+	UserNegativeCacheTTL time.Duration `long:"user-negative-cache-ttl" env:"USER_NEGATIVE_CACHE_TTL" description:"Lifetime of a cached not found or deactivated user" default:"1m"`
+
+	FloodWindows int           `long:"flood-windows" env:"FLOOD_WINDOWS" description:"Maximum number of sliding windows kept by flood rules" default:"10000"`
+	Fingerprints int           `long:"fingerprints" env:"FINGERPRINTS" description:"Maximum number of recent comment fingerprints kept by each copy-paste rule" default:"10000"`
+	HistoryTTL   time.Duration `long:"history-ttl" env:"HISTORY_TTL" description:"Lifetime of comment history of a user used by expression rules" default:"24h"`
+
+	UsersGetTimeout          time.Duration `long:"users-get-timeout" env:"USERS_GET_TIMEOUT" description:"Timeout of users.get VK API call" default:"5s"`
+	ResolveScreenNameTimeout time.Duration `long:"resolve-screen-name-timeout" env:"RESOLVE_SCREEN_NAME_TIMEOUT" description:"Timeout of utils.resolveScreenName VK API call" default:"5s"`
+	GroupsGetByIDTimeout     time.Duration `long:"groups-get-by-id-timeout" env:"GROUPS_GET_BY_ID_TIMEOUT" description:"Timeout of groups.getById VK API call" default:"5s"`
+	GroupsGetMembersTimeout  time.Duration `long:"groups-get-members-timeout" env:"GROUPS_GET_MEMBERS_TIMEOUT" description:"Timeout of groups.getMembers VK API call" default:"5s"`
+	GroupsBanTimeout         time.Duration `long:"groups-ban-timeout" env:"GROUPS_BAN_TIMEOUT" description:"Timeout of groups.ban VK API call" default:"5s"`
+	WallDeleteCommentTimeout time.Duration `long:"wall-delete-comment-timeout" env:"WALL_DELETE_COMMENT_TIMEOUT" description:"Timeout of wall.deleteComment VK API call" default:"5s"`
+	CheckLinkTimeout         time.Duration `long:"check-link-timeout" env:"CHECK_LINK_TIMEOUT" description:"Timeout of utils.checkLink VK API call" default:"5s"`
+	RegistrationDateTimeout  time.Duration `long:"registration-date-timeout" env:"REGISTRATION_DATE_TIMEOUT" description:"Timeout of user registration date request" default:"5s"`
+
+	GroupID                  int           `long:"group-id" env:"GROUP_ID" description:"Moderated community ID, its managers are never banned"`
+	AllowlistRefreshInterval time.Duration `long:"allowlist-refresh-interval" env:"ALLOWLIST_REFRESH_INTERVAL" description:"Interval between community managers and members refreshes, every refresh pages through all members" default:"1h"`
+	MemberMinAge             time.Duration `long:"member-min-age" env:"MEMBER_MIN_AGE" description:"Community members are never banned after this membership duration, disabled if zero"`
+	MembersPath              string        `long:"members-path" env:"MEMBERS_PATH" description:"Path to file with community members join time" default:"members.json"`
+	TrustExistingMembers     bool          `long:"trust-existing-members" env:"TRUST_EXISTING_MEMBERS" description:"Members found by the first sync are trusted right away instead of waiting for member-min-age"`
+
+	OutboxPath             string        `long:"outbox-path" env:"OUTBOX_PATH" description:"Path to file with pending moderation actions" default:"outbox.json"`
+	OutboxRetryInterval    time.Duration `long:"outbox-retry-interval" env:"OUTBOX_RETRY_INTERVAL" description:"Initial delay before a failed moderation action is retried" default:"30s"`
+	OutboxMaxRetryInterval time.Duration `long:"outbox-max-retry-interval" env:"OUTBOX_MAX_RETRY_INTERVAL" description:"Maximum delay before a failed moderation action is retried" default:"1h"`
+
+	AuditPath string `long:"audit-path" env:"AUDIT_PATH" description:"Path to file where decisions about matched comments are appended, disabled if empty"`
 }
 
 // ParseConfig parses banhammer config.
@@ -26,3 +57,21 @@ func ParseConfig() (*Config, error) {
 
 	return &cfg, nil
 }
+
+// TrainConfig is a config of the train command.
+type TrainConfig struct {
+	InputPath  string `long:"input-path" description:"Path to JSONL file with labeled comments, one {\"text\": ..., \"spam\": ...} object per line" required:"true"`
+	OutputPath string `long:"output-path" description:"Path to the model file" default:"model.json"`
+	MinCount   int    `long:"min-count" description:"Tokens seen less times are dropped from the model" default:"2"`
+}
+
+// ParseTrainConfig parses config of the train command from its arguments.
+func ParseTrainConfig(args []string) (*TrainConfig, error) {
+	var cfg TrainConfig
+
+	if _, err := flags.ParseArgs(&cfg, args); err != nil {
+		return nil, fmt.Errorf("failed to parse: %w", err)
+	}
+
+	return &cfg, nil
+}
@@ -0,0 +1,172 @@
+package copypaste
+
+import (
+	"hash/fnv"
+	"math/bits"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sklyar/vk-banhammer/internal/textnorm"
+)
+
+// shingleSize is a number of words in one simhash feature.
+const shingleSize = 3
+
+// Fingerprint returns simhash of normalized text and number of its words.
+// Near-identical texts have fingerprints with a small Hamming distance.
+func Fingerprint(text string) (uint64, int) {
+	words := strings.Fields(textnorm.Normalize(text))
+	if len(words) == 0 {
+		return 0, 0
+	}
+
+	n := shingleSize
+	if len(words) < n {
+		n = len(words)
+	}
+
+	var weights [64]int
+	for i := 0; i+n <= len(words); i++ {
+		h := fnv.New64a()
+		_, _ = h.Write([]byte(strings.Join(words[i:i+n], " ")))
+		sum := h.Sum64()
+
+		for bit := range weights {
+			if sum&(1<<bit) != 0 {
+				weights[bit]++
+			} else {
+				weights[bit]--
+			}
+		}
+	}
+
+	var hash uint64
+	for bit, w := range weights {
+		if w > 0 {
+			hash |= 1 << bit
+		}
+	}
+
+	return hash, len(words)
+}
+
+// Distance returns Hamming distance between fingerprints.
+func Distance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
+
+// Entry is a fingerprinted comment.
+type Entry struct {
+	Hash      uint64
+	FromID    int
+	OwnerID   int
+	CommentID int
+	At        time.Time
+
+	// reported is true when the entry was already returned as a copy.
+	reported bool
+}
+
+// Detector keeps recent fingerprints and finds texts posted by many authors.
+// Memory is bounded, the oldest fingerprints are overwritten.
+type Detector struct {
+	window      time.Duration
+	maxDistance int
+	minAuthors  int
+
+	entries []Entry
+	next    int
+	m       sync.Mutex
+}
+
+// New creates a new detector keeping at most size fingerprints.
+// Text is considered copy-pasted once minAuthors distinct authors
+// have posted near-identical text within the window.
+func New(size int, window time.Duration, maxDistance, minAuthors int) *Detector {
+	if size < 1 {
+		size = 1
+	}
+
+	return &Detector{
+		window:      window,
+		maxDistance: maxDistance,
+		minAuthors:  minAuthors,
+		entries:     make([]Entry, 0, size),
+	}
+}
+
+// Add records entry and reports whether its text is copy-pasted.
+// When it is, earlier copies are returned, each of them is returned only once.
+func (d *Detector) Add(e Entry) ([]Entry, bool) {
+	d.m.Lock()
+	defer d.m.Unlock()
+
+	similar, ok := d.find(e)
+	if !ok {
+		d.add(e)
+		return nil, false
+	}
+
+	var copies []Entry
+	for _, i := range similar {
+		if d.entries[i].reported {
+			continue
+		}
+		d.entries[i].reported = true
+		copies = append(copies, d.entries[i])
+	}
+
+	// The matched entry itself is punished by the caller.
+	e.reported = true
+	d.add(e)
+
+	return copies, true
+}
+
+// Check reports whether text of the entry is copy-pasted and returns earlier copies,
+// which are not returned yet, without recording the entry.
+func (d *Detector) Check(e Entry) ([]Entry, bool) {
+	d.m.Lock()
+	defer d.m.Unlock()
+
+	similar, ok := d.find(e)
+	if !ok {
+		return nil, false
+	}
+
+	var copies []Entry
+	for _, i := range similar {
+		if !d.entries[i].reported {
+			copies = append(copies, d.entries[i])
+		}
+	}
+
+	return copies, true
+}
+
+// find returns indexes of entries similar to the given one within the window
+// and reports whether they have enough authors.
+func (d *Detector) find(e Entry) ([]int, bool) {
+	from := e.At.Add(-d.window)
+	authors := map[int]struct{}{e.FromID: {}}
+	var similar []int
+	for i, other := range d.entries {
+		if !other.At.After(from) || Distance(e.Hash, other.Hash) > d.maxDistance {
+			continue
+		}
+		authors[other.FromID] = struct{}{}
+		similar = append(similar, i)
+	}
+
+	return similar, len(authors) >= d.minAuthors
+}
+
+func (d *Detector) add(e Entry) {
+	if len(d.entries) < cap(d.entries) {
+		d.entries = append(d.entries, e)
+		return
+	}
+	d.entries[d.next] = e
+	d.next = (d.next + 1) % len(d.entries)
+}
@@ -0,0 +1,133 @@
+package copypaste
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFingerprint(t *testing.T) {
+	t.Parallel()
+
+	const text = "Заработок от 5000 рублей в день без вложений, пиши в личку"
+
+	tests := []struct {
+		name  string
+		other string
+		near  bool
+	}{
+		{
+			name:  "same text",
+			other: text,
+			near:  true,
+		},
+		{
+			name:  "case and punctuation",
+			other: "заработок от 5000 рублей в день БЕЗ вложений!!! пиши в личку",
+			near:  true,
+		},
+		{
+			name:  "latin homoglyphs",
+			other: "Зapaбoтoк от 5000 рублей в день без вложений, пиши в личку",
+			near:  true,
+		},
+		{
+			name:  "different text",
+			other: "Спасибо за статью, было очень интересно читать про историю города",
+			near:  false,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			a, _ := Fingerprint(text)
+			b, _ := Fingerprint(tt.other)
+			if got := Distance(a, b) <= 3; got != tt.near {
+				t.Errorf("Distance() = %v, want near %v", Distance(a, b), tt.near)
+			}
+		})
+	}
+}
+
+func TestDetector_Add(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	hash, _ := Fingerprint("Заработок от 5000 рублей в день без вложений")
+	other, _ := Fingerprint("Спасибо за статью, было очень интересно")
+
+	d := New(100, time.Minute, 3, 3)
+	entries := []struct {
+		entry      Entry
+		wantCopies int
+		want       bool
+	}{
+		{entry: Entry{Hash: hash, FromID: 1, CommentID: 1, At: now}},
+		// The same author is counted once.
+		{entry: Entry{Hash: hash, FromID: 1, CommentID: 2, At: now.Add(time.Second)}},
+		{entry: Entry{Hash: other, FromID: 2, CommentID: 3, At: now.Add(2 * time.Second)}},
+		{entry: Entry{Hash: hash, FromID: 3, CommentID: 4, At: now.Add(3 * time.Second)}},
+		{entry: Entry{Hash: hash, FromID: 4, CommentID: 5, At: now.Add(4 * time.Second)}, wantCopies: 3, want: true},
+		// Copies are returned only once.
+		{entry: Entry{Hash: hash, FromID: 5, CommentID: 6, At: now.Add(5 * time.Second)}, wantCopies: 0, want: true},
+		// Copies out of the window are ignored.
+		{entry: Entry{Hash: hash, FromID: 6, CommentID: 7, At: now.Add(10 * time.Minute)}},
+	}
+	for _, e := range entries {
+		copies, got := d.Add(e.entry)
+		if got != e.want {
+			t.Errorf("Add() comment %d got = %v, want %v", e.entry.CommentID, got, e.want)
+		}
+		if len(copies) != e.wantCopies {
+			t.Errorf("Add() comment %d got %d copies, want %d", e.entry.CommentID, len(copies), e.wantCopies)
+		}
+	}
+}
+
+func TestDetector_Check(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	hash, _ := Fingerprint("Заработок от 5000 рублей в день без вложений")
+
+	d := New(100, time.Minute, 3, 2)
+	d.Add(Entry{Hash: hash, FromID: 1, CommentID: 1, At: now})
+
+	// Checked entries are neither recorded nor mark copies as returned.
+	for i := 0; i < 2; i++ {
+		copies, got := d.Check(Entry{Hash: hash, FromID: 2, CommentID: 2, At: now.Add(time.Second)})
+		if !got || len(copies) != 1 {
+			t.Errorf("Check() got = %v with %d copies, want true with 1 copy", got, len(copies))
+		}
+	}
+	if _, got := d.Check(Entry{Hash: hash, FromID: 1, CommentID: 3, At: now.Add(time.Second)}); got {
+		t.Errorf("Check() got = %v, want false", got)
+	}
+
+	copies, got := d.Add(Entry{Hash: hash, FromID: 2, CommentID: 2, At: now.Add(time.Second)})
+	if !got || len(copies) != 1 {
+		t.Errorf("Add() got = %v with %d copies, want true with 1 copy", got, len(copies))
+	}
+}
+
+func TestDetector_Bounded(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	hash, _ := Fingerprint("Заработок от 5000 рублей в день без вложений")
+	other, _ := Fingerprint("Спасибо за статью, было очень интересно")
+
+	d := New(2, time.Minute, 3, 2)
+	d.Add(Entry{Hash: hash, FromID: 1, At: now})
+	// The first entry is overwritten.
+	d.Add(Entry{Hash: other, FromID: 2, At: now})
+	d.Add(Entry{Hash: other, FromID: 2, At: now})
+
+	if _, got := d.Add(Entry{Hash: hash, FromID: 3, At: now}); got {
+		t.Errorf("Add() got = %v, want %v", got, false)
+	}
+	if len(d.entries) != 2 {
+		t.Errorf("len(entries) = %d, want %d", len(d.entries), 2)
+	}
+}
@@ -0,0 +1,57 @@
+package entity
+
+import (
+	"strconv"
+	"time"
+)
+
+// ActionType describes type of moderation action.
+type ActionType string
+
+// Available action types.
+const (
+	ActionTypeBan           ActionType = "ban"
+	ActionTypeDeleteComment ActionType = "delete_comment"
+)
+
+// Action describes a pending moderation action.
+type Action struct {
+	ID   string     `json:"id"`
+	Type ActionType `json:"type"`
+
+	// OwnerID is an ID of the wall owner, it is negative for groups.
+	OwnerID   int       `json:"owner_id"`
+	UserID    int       `json:"user_id,omitempty"`
+	CommentID int       `json:"comment_id,omitempty"`
+	Reason    BanReason `json:"reason,omitempty"`
+
+	Attempts      int       `json:"attempts"`
+	LastError     string    `json:"last_error,omitempty"`
+	NextAttemptAt time.Time `json:"next_attempt_at"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// NewBanAction creates an action banning user in the group owning the wall.
+func NewBanAction(ownerID, userID int, reason BanReason, now time.Time) Action {
+	return Action{
+		ID:            string(ActionTypeBan) + ":" + strconv.Itoa(ownerID) + ":" + strconv.Itoa(userID),
+		Type:          ActionTypeBan,
+		OwnerID:       ownerID,
+		UserID:        userID,
+		Reason:        reason,
+		NextAttemptAt: now,
+		CreatedAt:     now,
+	}
+}
+
+// NewDeleteCommentAction creates an action deleting comment from the wall.
+func NewDeleteCommentAction(ownerID, commentID int, now time.Time) Action {
+	return Action{
+		ID:            string(ActionTypeDeleteComment) + ":" + strconv.Itoa(ownerID) + ":" + strconv.Itoa(commentID),
+		Type:          ActionTypeDeleteComment,
+		OwnerID:       ownerID,
+		CommentID:     commentID,
+		NextAttemptAt: now,
+		CreatedAt:     now,
+	}
+}
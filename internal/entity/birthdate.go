@@ -0,0 +1,60 @@
+package entity
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// BirthDate is a birthdate in VK format: "D.M.YYYY" or "D.M" when year is hidden.
+type BirthDate struct {
+	Day   int
+	Month int
+	// Year is 0 when it is hidden.
+	Year int
+}
+
+// ParseBirthDate parses birthdate in VK format.
+func ParseBirthDate(s string) (BirthDate, bool) {
+	parts := strings.Split(s, ".")
+	if len(parts) != 2 && len(parts) != 3 {
+		return BirthDate{}, false
+	}
+
+	values := make([]int, len(parts))
+	for i, p := range parts {
+		v, err := strconv.Atoi(p)
+		if err != nil {
+			return BirthDate{}, false
+		}
+		values[i] = v
+	}
+
+	d := BirthDate{Day: values[0], Month: values[1]}
+	if len(values) == 3 {
+		d.Year = values[2]
+	}
+
+	return d, true
+}
+
+// HasYear reports whether year is known.
+func (d BirthDate) HasYear() bool {
+	return d.Year != 0
+}
+
+// SameDay reports whether birthdates have the same day and month.
+func (d BirthDate) SameDay(other BirthDate) bool {
+	return d.Day == other.Day && d.Month == other.Month
+}
+
+// Age returns full years at the given time.
+// It must be called only for birthdates with known year.
+func (d BirthDate) Age(now time.Time) int {
+	age := now.Year() - d.Year
+	if int(now.Month()) < d.Month || (int(now.Month()) == d.Month && now.Day() < d.Day) {
+		age--
+	}
+
+	return age
+}
@@ -1,11 +1,128 @@
 package entity
 
+import (
+	"strings"
+
+	"github.com/sklyar/vk-banhammer/internal/spampattern"
+	"github.com/sklyar/vk-banhammer/internal/textnorm"
+)
+
 // Comment describes comment.
 type Comment struct {
+	ID          int          `json:"id"`
+	FromID      int          `json:"from_id"`
+	Date        int          `json:"date"`
+	Text        string       `json:"text"`
+	PostID      int          `json:"post_id"`
+	OwnerID     int          `json:"owner_id"`
+	Attachments []Attachment `json:"attachments"`
+
+	// normalized caches normalized text.
+	normalized *string
+}
+
+// Attachment types used in rules.
+const (
+	AttachmentTypePhoto   = "photo"
+	AttachmentTypeVideo   = "video"
+	AttachmentTypeAudio   = "audio"
+	AttachmentTypeDoc     = "doc"
+	AttachmentTypeLink    = "link"
+	AttachmentTypeSticker = "sticker"
+)
+
+// Attachment describes comment attachment.
+// Only the field of the attachment type is set.
+type Attachment struct {
+	Type    string             `json:"type"`
+	Photo   *PhotoAttachment   `json:"photo,omitempty"`
+	Video   *VideoAttachment   `json:"video,omitempty"`
+	Audio   *AudioAttachment   `json:"audio,omitempty"`
+	Doc     *DocAttachment     `json:"doc,omitempty"`
+	Link    *LinkAttachment    `json:"link,omitempty"`
+	Sticker *StickerAttachment `json:"sticker,omitempty"`
+}
+
+// PhotoAttachment describes attached photo.
+type PhotoAttachment struct {
 	ID      int    `json:"id"`
-	FromID  int    `json:"from_id"`
-	Date    int    `json:"date"`
+	OwnerID int    `json:"owner_id"`
 	Text    string `json:"text"`
-	PostID  int    `json:"post_id"`
+}
+
+// VideoAttachment describes attached video.
+type VideoAttachment struct {
+	ID          int    `json:"id"`
+	OwnerID     int    `json:"owner_id"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+}
+
+// AudioAttachment describes attached audio.
+type AudioAttachment struct {
+	ID      int    `json:"id"`
 	OwnerID int    `json:"owner_id"`
+	Artist  string `json:"artist"`
+	Title   string `json:"title"`
+}
+
+// DocAttachment describes attached document.
+type DocAttachment struct {
+	ID      int    `json:"id"`
+	OwnerID int    `json:"owner_id"`
+	Title   string `json:"title"`
+	Ext     string `json:"ext"`
+	URL     string `json:"url"`
+}
+
+// LinkAttachment describes attached link.
+type LinkAttachment struct {
+	URL   string `json:"url"`
+	Title string `json:"title"`
+}
+
+// StickerAttachment describes attached sticker.
+type StickerAttachment struct {
+	ProductID int `json:"product_id"`
+	StickerID int `json:"sticker_id"`
+}
+
+// NormalizedText returns comment text normalized for text rules.
+func (c *Comment) NormalizedText() string {
+	if c.normalized == nil {
+		normalized := textnorm.Normalize(c.Text)
+		c.normalized = &normalized
+	}
+
+	return *c.normalized
+}
+
+// Mentions returns mentions from comment text.
+func (c *Comment) Mentions() []Mention {
+	return ParseMentions(c.Text)
+}
+
+// Links returns links from comment text and attachments.
+func (c *Comment) Links() []string {
+	links := ParseLinks(c.Text)
+	for _, a := range c.Attachments {
+		if a.Link != nil && a.Link.URL != "" {
+			links = append(links, a.Link.URL)
+		}
+	}
+
+	return links
+}
+
+// SpamEntities returns phones, messenger handles, wallets and card numbers
+// from comment text and attached links.
+func (c *Comment) SpamEntities() []spampattern.Entity {
+	parts := []string{c.Text}
+	for _, a := range c.Attachments {
+		if a.Link != nil {
+			parts = append(parts, a.Link.URL)
+		}
+	}
+
+	return spampattern.Find(strings.Join(parts, "\n"))
 }
@@ -0,0 +1,38 @@
+package entity
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestComment_UnmarshalAttachments(t *testing.T) {
+	t.Parallel()
+
+	// Object of wall_reply_new callback event.
+	data := `{
+		"id": 1, "from_id": 87524863, "date": 1580000000, "text": "", "post_id": 911, "owner_id": -61061413,
+		"attachments": [
+			{"type": "sticker", "sticker": {"product_id": 1, "sticker_id": 2}},
+			{"type": "doc", "doc": {"id": 3, "owner_id": 4, "title": "free.apk", "ext": "apk", "url": "https://vk.com/doc4_3"}},
+			{"type": "link", "link": {"url": "https://example.com", "title": "Example"}}
+		]
+	}`
+
+	var got Comment
+	if err := json.Unmarshal([]byte(data), &got); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	want := []Attachment{
+		{Type: AttachmentTypeSticker, Sticker: &StickerAttachment{ProductID: 1, StickerID: 2}},
+		{Type: AttachmentTypeDoc, Doc: &DocAttachment{ID: 3, OwnerID: 4, Title: "free.apk", Ext: "apk", URL: "https://vk.com/doc4_3"}},
+		{Type: AttachmentTypeLink, Link: &LinkAttachment{URL: "https://example.com", Title: "Example"}},
+	}
+	if !reflect.DeepEqual(got.Attachments, want) {
+		t.Errorf("Unmarshal() attachments = %+v, want %+v", got.Attachments, want)
+	}
+	if links := got.Links(); !reflect.DeepEqual(links, []string{"https://example.com"}) {
+		t.Errorf("Links() = %v, want %v", links, []string{"https://example.com"})
+	}
+}
@@ -0,0 +1,202 @@
+package entity
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/SevereCloud/vksdk/v2/object"
+	"github.com/sklyar/vk-banhammer/internal/langdetect"
+	"github.com/sklyar/vk-banhammer/internal/spampattern"
+)
+
+// Decision explains how comment was checked and what was done with it.
+type Decision struct {
+	CommentID int `json:"comment_id"`
+	FromID    int `json:"from_id"`
+	OwnerID   int `json:"owner_id"`
+
+	// Reason is a reason of the first matched rule, it is "none" if no rule matched.
+	Reason     BanReason  `json:"reason"`
+	Punishment Punishment `json:"punishment,omitempty"`
+	Score      float64    `json:"score,omitempty"`
+	Entities   []string   `json:"entities,omitempty"`
+
+	// Skipped explains why comment was not checked, like "allowlist" or "deactivated".
+	Skipped string `json:"skipped,omitempty"`
+	// DryRun is set when comment is only evaluated, no actions are performed and no state is recorded.
+	DryRun bool `json:"dry_run,omitempty"`
+	// Rules contains evaluated rules in order, checks usually stop at the first matched rule.
+	Rules []RuleResult `json:"rules,omitempty"`
+	// Actions contains VK actions performed for the comment, including deletion of copy-pasted copies.
+	Actions []ActionResult `json:"actions,omitempty"`
+}
+
+// RuleResult describes evaluated rule.
+type RuleResult struct {
+	// Rule is a rule section and 0-based index of the rule in it, like "style[1]".
+	Rule    string `json:"rule"`
+	Matched bool   `json:"matched"`
+	// Score is a similarity or probability computed by the rule, if any.
+	Score float64 `json:"score,omitempty"`
+	// Values contains values of the comment or its author compared by the rule.
+	Values map[string]any `json:"values,omitempty"`
+}
+
+// ActionResult describes performed VK action.
+type ActionResult struct {
+	Type      ActionType `json:"type"`
+	OwnerID   int        `json:"owner_id"`
+	UserID    int        `json:"user_id,omitempty"`
+	CommentID int        `json:"comment_id,omitempty"`
+	// Error is empty if action succeeded, failed actions may be retried later.
+	Error string `json:"error,omitempty"`
+}
+
+// NewDecision creates decision for comment which matched no rule yet.
+func NewDecision(comment *Comment) Decision {
+	return Decision{
+		CommentID: comment.ID,
+		FromID:    comment.FromID,
+		OwnerID:   comment.OwnerID,
+		Reason:    BanReasonNone,
+	}
+}
+
+// Matched reports whether any rule matched.
+func (d *Decision) Matched() bool {
+	return d.Reason != BanReasonNone
+}
+
+// SetMatch stores the matched rule in decision.
+func (d *Decision) SetMatch(m Match) {
+	d.Reason = m.Reason
+	d.Punishment = m.Punishment
+	d.Score = m.Score
+	d.Entities = m.Entities
+}
+
+// AddRule records evaluated rule, nil decision records nothing.
+func (d *Decision) AddRule(section string, index int, matched bool, score float64, values map[string]any) {
+	if d == nil {
+		return
+	}
+
+	d.Rules = append(d.Rules, RuleResult{
+		Rule:    section + "[" + strconv.Itoa(index) + "]",
+		Matched: matched,
+		Score:   score,
+		Values:  values,
+	})
+}
+
+// AddAction records performed action, nil decision records nothing.
+func (d *Decision) AddAction(action Action, err error) {
+	if d == nil {
+		return
+	}
+
+	res := ActionResult{
+		Type:      action.Type,
+		OwnerID:   action.OwnerID,
+		UserID:    action.UserID,
+		CommentID: action.CommentID,
+	}
+	if err != nil {
+		res.Error = err.Error()
+	}
+	d.Actions = append(d.Actions, res)
+}
+
+// userValues returns every user field compared by person non grata rules.
+func userValues(user *object.UsersUser) map[string]any {
+	values := map[string]any{
+		"name":            user.FirstName + " " + user.LastName,
+		"birth_date":      user.Bdate,
+		"maiden_name":     user.MaidenName,
+		"nickname":        user.Nickname,
+		"domain":          user.Domain,
+		"city":            user.City.Title,
+		"country":         user.Country.Title,
+		"sex":             user.Sex,
+		"status":          user.Status,
+		"site":            user.Site,
+		"has_photo":       bool(user.HasPhoto),
+		"is_closed":       bool(user.IsClosed),
+		"deactivated":     user.Deactivated,
+		"followers_count": user.FollowersCount,
+	}
+	if bdate, ok := ParseBirthDate(user.Bdate); ok && bdate.HasYear() {
+		values["age"] = bdate.Age(time.Now())
+	}
+
+	return values
+}
+
+func communityValues(group *object.GroupsGroup) map[string]any {
+	return map[string]any{
+		"name":          group.Name,
+		"screen_name":   group.ScreenName,
+		"verified":      bool(group.Verified),
+		"members_count": group.MembersCount,
+	}
+}
+
+func mentionValues(mentions []Mention) map[string]any {
+	screenNames := make([]string, 0, len(mentions))
+	for _, m := range mentions {
+		if m.ScreenName != "" {
+			screenNames = append(screenNames, m.ScreenName)
+		}
+	}
+
+	return map[string]any{
+		"users":        countMentions(mentions, MentionTypeUser),
+		"communities":  countMentions(mentions, MentionTypeCommunity),
+		"screen_names": screenNames,
+	}
+}
+
+func styleValues(style TextStyle) map[string]any {
+	return map[string]any{
+		"length":          style.Length,
+		"caps_ratio":      style.CapsRatio(),
+		"emoji_ratio":     style.EmojiRatio(),
+		"max_repeat":      style.MaxRepeat,
+		"link_density":    style.LinkDensity(),
+		"mention_density": style.MentionDensity(),
+	}
+}
+
+func attachmentValues(attachments []Attachment) map[string]any {
+	types := make([]string, 0, len(attachments))
+	for _, a := range attachments {
+		types = append(types, a.Type)
+	}
+
+	return map[string]any{"types": types}
+}
+
+func profanityValues(c ProfanityCheck) map[string]any {
+	return map[string]any{
+		"words":        c.Words,
+		"obfuscated":   c.Obfuscated(),
+		"mixed_script": c.MixedScript,
+	}
+}
+
+func spamPatternValues(entities []spampattern.Entity) map[string]any {
+	values := make([]string, 0, len(entities))
+	for _, e := range entities {
+		values = append(values, e.String())
+	}
+
+	return map[string]any{"entities": values}
+}
+
+func languageValues(language langdetect.Result) map[string]any {
+	return map[string]any{
+		"language":     language.Language,
+		"letters":      language.Letters,
+		"non_cyrillic": 1 - language.Share(langdetect.ScriptCyrillic),
+	}
+}
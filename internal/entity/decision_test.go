@@ -0,0 +1,88 @@
+package entity
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/SevereCloud/vksdk/v2/object"
+)
+
+func TestHeuristicRulesCheckComment_Decision(t *testing.T) {
+	t.Parallel()
+
+	rules := HeuristicRules{
+		Style: []HeuristicStyleRule{
+			{MinEmojiRatio: toPtr(0.5)},
+			{MinCapsRatio: toPtr(0.7), MinLength: toPtr(10), Action: PunishmentDelete},
+			{MinRepeatRun: toPtr(3)},
+		},
+	}
+	comment := &Comment{ID: 1, FromID: 87524863, OwnerID: -61061413, Text: "СРОЧНО ПИШИ МНЕ"}
+
+	d := NewDecision(comment)
+	match, matched := rules.CheckComment(comment, &d)
+	if !matched {
+		t.Fatalf("CheckComment() matched = false, want true")
+	}
+	d.SetMatch(match)
+
+	if d.Reason != BanReasonStyle || d.Punishment != PunishmentDelete || !d.Matched() {
+		t.Errorf("Decision = %+v, want style delete", d)
+	}
+
+	// The third rule is not evaluated after the second one matched.
+	var got []string
+	for _, r := range d.Rules {
+		got = append(got, r.Rule)
+		if r.Values["caps_ratio"] != 1.0 {
+			t.Errorf("rule %s caps_ratio = %v, want 1", r.Rule, r.Values["caps_ratio"])
+		}
+	}
+	if want := []string{"style[0]", "style[1]"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Decision.Rules = %v, want %v", got, want)
+	}
+	if d.Rules[0].Matched || !d.Rules[1].Matched {
+		t.Errorf("Decision.Rules = %+v, want only style[1] matched", d.Rules)
+	}
+}
+
+func TestHeuristicRulesCheck_DecisionValues(t *testing.T) {
+	t.Parallel()
+
+	rules := HeuristicRules{
+		PersonNonGrata: []HeuristicPersonNonGrataRule{
+			{Domain: toPtr("bob"), Site: toPtr("scam.ru"), MinFollowersCount: toPtr(1000)},
+		},
+	}
+	user := &object.UsersUser{
+		FirstName:      "Bob",
+		LastName:       "Marley",
+		Bdate:          "6.2.1945",
+		Domain:         "bob",
+		Site:           "https://scam.ru",
+		FollowersCount: 5000,
+	}
+
+	d := NewDecision(&Comment{ID: 1, FromID: 87524863, OwnerID: -61061413})
+	if _, matched := rules.Check(user, &d); !matched {
+		t.Fatalf("Check() matched = false, want true")
+	}
+
+	want := map[string]any{"domain": "bob", "site": "https://scam.ru", "followers_count": 5000}
+	for k, v := range want {
+		if got := d.Rules[0].Values[k]; got != v {
+			t.Errorf("rule %s %s = %v, want %v", d.Rules[0].Rule, k, got, v)
+		}
+	}
+	if _, ok := d.Rules[0].Values["age"]; !ok {
+		t.Errorf("rule %s values = %v, want age", d.Rules[0].Rule, d.Rules[0].Values)
+	}
+}
+
+func TestDecision_NilRecordsNothing(t *testing.T) {
+	t.Parallel()
+
+	var d *Decision
+	d.AddRule("style", 0, true, 1, nil)
+	d.AddAction(Action{Type: ActionTypeBan}, nil)
+}
@@ -0,0 +1,311 @@
+package entity
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/SevereCloud/vksdk/v2/object"
+	"github.com/sklyar/vk-banhammer/internal/expr"
+	"github.com/sklyar/vk-banhammer/internal/langdetect"
+	"github.com/sklyar/vk-banhammer/internal/textnorm"
+)
+
+// CommentHistory describes earlier comments of the author.
+type CommentHistory struct {
+	// Comments is a number of earlier comments.
+	Comments int
+	// Punished is a number of earlier comments which were deleted or got the author banned.
+	Punished int
+}
+
+// expressionConfig declares variables and functions of expression rules.
+var expressionConfig = expr.Config{
+	Vars: map[string]expr.Type{
+		"user.id":              expr.TypeInt,
+		"user.name":            expr.TypeString,
+		"user.first_name":      expr.TypeString,
+		"user.last_name":       expr.TypeString,
+		"user.birth_date":      expr.TypeString,
+		"user.age":             expr.TypeInt,
+		"user.city":            expr.TypeString,
+		"user.country":         expr.TypeString,
+		"user.sex":             expr.TypeString,
+		"user.status":          expr.TypeString,
+		"user.followers_count": expr.TypeInt,
+		"user.has_photo":       expr.TypeBool,
+		"user.is_closed":       expr.TypeBool,
+		"user.deactivated":     expr.TypeString,
+
+		"comment.text":            expr.TypeString,
+		"comment.normalized_text": expr.TypeString,
+		"comment.from_community":  expr.TypeBool,
+		"comment.length":          expr.TypeInt,
+		"comment.words":           expr.TypeInt,
+		"comment.links":           expr.TypeStrings,
+		"comment.mentions":        expr.TypeInt,
+		"comment.attachments":     expr.TypeStrings,
+		"comment.entities":        expr.TypeStrings,
+		"comment.language":        expr.TypeString,
+		"comment.caps_ratio":      expr.TypeFloat,
+		"comment.emoji_ratio":     expr.TypeFloat,
+		"comment.max_repeat":      expr.TypeInt,
+		"comment.profanity":       expr.TypeInt,
+
+		"post.id":       expr.TypeInt,
+		"post.owner_id": expr.TypeInt,
+
+		"history.comments": expr.TypeInt,
+		"history.punished": expr.TypeInt,
+	},
+	Funcs: map[string]expr.Func{
+		"similar": {
+			Args:   []expr.Type{expr.TypeString, expr.TypeString},
+			Result: expr.TypeFloat,
+			Fn:     func(args []any) any { return NameSimilarity(args[0].(string), args[1].(string)) },
+		},
+		"normalize": {
+			Args:   []expr.Type{expr.TypeString},
+			Result: expr.TypeString,
+			Fn:     func(args []any) any { return textnorm.Normalize(args[0].(string)) },
+		},
+	},
+}
+
+// expressionUserFields maps variables to VK API user fields they need.
+// Name, is_closed and deactivated are always returned.
+var expressionUserFields = map[string]string{
+	"user.birth_date":      "bdate",
+	"user.age":             "bdate",
+	"user.city":            "city",
+	"user.country":         "country",
+	"user.sex":             "sex",
+	"user.status":          "status",
+	"user.followers_count": "followers_count",
+	"user.has_photo":       "has_photo",
+}
+
+// CompileExpression compiles and type-checks expression of expression rule.
+func CompileExpression(source string) (*expr.Program, error) {
+	return expr.Compile(source, expressionConfig)
+}
+
+// HeuristicExpressionRule describes rule with a boolean expression over user, comment, post and history,
+// like `(user.name == "Иван Иванов" or user.birth_date == "1.11.2000") and len(comment.links) > 0`.
+type HeuristicExpressionRule struct {
+	Expression string `toml:"expression"`
+	// Action is a punishment for the comment, it is "ban" by default.
+	Action Punishment `toml:"action"`
+
+	program *expr.Program
+}
+
+// Compile compiles expression of the rule.
+func (r *HeuristicExpressionRule) Compile() error {
+	p, err := CompileExpression(r.Expression)
+	if err != nil {
+		return fmt.Errorf("failed to compile expression %q: %w", r.Expression, err)
+	}
+	r.program = p
+
+	return nil
+}
+
+// Check checks if expression is true, rules which are not compiled never match.
+func (r HeuristicExpressionRule) Check(env *ExpressionEnv) bool {
+	return r.program != nil && r.program.Eval(env)
+}
+
+// Match returns match for the expression rule.
+func (r HeuristicExpressionRule) Match() Match {
+	m := exactMatch(BanReasonExpression)
+	if r.Action != "" {
+		m.Punishment = r.Action
+	}
+
+	return m
+}
+
+// Fields returns VK API user fields used by the expression.
+func (r HeuristicExpressionRule) Fields() []string {
+	if r.program == nil {
+		return nil
+	}
+
+	var fields []string
+	for _, v := range r.program.Vars() {
+		if f, ok := expressionUserFields[v]; ok && !containsFold(fields, f) {
+			fields = append(fields, f)
+		}
+	}
+
+	return fields
+}
+
+// values returns values of variables used by the expression.
+func (r HeuristicExpressionRule) values(env *ExpressionEnv) map[string]any {
+	if r.program == nil {
+		return nil
+	}
+
+	values := make(map[string]any, len(r.program.Vars()))
+	for _, v := range r.program.Vars() {
+		values[v] = env.Get(v)
+	}
+
+	return values
+}
+
+// usesVar reports whether the expression uses the variable.
+func (r HeuristicExpressionRule) usesVar(name string) bool {
+	if r.program == nil {
+		return false
+	}
+
+	for _, v := range r.program.Vars() {
+		if v == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ExpressionEnv provides values of expression variables, they are computed on first use.
+type ExpressionEnv struct {
+	// User is an author of the comment, it is nil for communities.
+	// User variables are missing then, expressions over missing values never match, see expr.Env.
+	User    *object.UsersUser
+	Comment *Comment
+	History CommentHistory
+
+	rules  *HeuristicRules
+	style  *TextStyle
+	values map[string]any
+}
+
+// Get returns value of the variable.
+func (e *ExpressionEnv) Get(name string) any {
+	if v, ok := e.values[name]; ok {
+		return v
+	}
+
+	v := e.value(name)
+	if e.values == nil {
+		e.values = make(map[string]any)
+	}
+	e.values[name] = v
+
+	return v
+}
+
+// value computes value of the variable.
+// User variables are missing for communities, age is missing if birth year is hidden.
+func (e *ExpressionEnv) value(name string) any {
+	user := e.User
+	if user == nil && strings.HasPrefix(name, "user.") {
+		return nil
+	}
+
+	switch name {
+	case "user.id":
+		return user.ID
+	case "user.name":
+		return strings.TrimSpace(user.FirstName + " " + user.LastName)
+	case "user.first_name":
+		return user.FirstName
+	case "user.last_name":
+		return user.LastName
+	case "user.birth_date":
+		return user.Bdate
+	case "user.age":
+		if bdate, ok := ParseBirthDate(user.Bdate); ok && bdate.HasYear() {
+			return bdate.Age(time.Now())
+		}
+		return nil
+	case "user.city":
+		return user.City.Title
+	case "user.country":
+		return user.Country.Title
+	case "user.sex":
+		for sex, v := range vkSex {
+			if user.Sex == v {
+				return sex
+			}
+		}
+		return ""
+	case "user.status":
+		return user.Status
+	case "user.followers_count":
+		return user.FollowersCount
+	case "user.has_photo":
+		return bool(user.HasPhoto)
+	case "user.is_closed":
+		return bool(user.IsClosed)
+	case "user.deactivated":
+		return user.Deactivated
+
+	case "comment.text":
+		return e.Comment.Text
+	case "comment.normalized_text":
+		return e.Comment.NormalizedText()
+	case "comment.from_community":
+		return e.Comment.FromID < 0
+	case "comment.length":
+		return e.textStyle().Length
+	case "comment.words":
+		return e.textStyle().Words
+	case "comment.links":
+		links := e.Comment.Links()
+		hosts := make([]string, 0, len(links))
+		for _, link := range links {
+			hosts = append(hosts, LinkHost(link))
+		}
+		return hosts
+	case "comment.mentions":
+		return e.textStyle().Mentions
+	case "comment.attachments":
+		types := make([]string, 0, len(e.Comment.Attachments))
+		for _, a := range e.Comment.Attachments {
+			types = append(types, a.Type)
+		}
+		return types
+	case "comment.entities":
+		var kinds []string
+		for _, entity := range e.Comment.SpamEntities() {
+			kinds = append(kinds, string(entity.Kind))
+		}
+		return kinds
+	case "comment.language":
+		return langdetect.Detect(e.Comment.Text).Language
+	case "comment.caps_ratio":
+		return e.textStyle().CapsRatio()
+	case "comment.emoji_ratio":
+		return e.textStyle().EmojiRatio()
+	case "comment.max_repeat":
+		return e.textStyle().MaxRepeat
+	case "comment.profanity":
+		return len(e.rules.checkProfanity(e.Comment).Words)
+
+	case "post.id":
+		return e.Comment.PostID
+	case "post.owner_id":
+		return e.Comment.OwnerID
+
+	case "history.comments":
+		return e.History.Comments
+	case "history.punished":
+		return e.History.Punished
+	}
+
+	panic(fmt.Sprintf("unknown expression variable %q", name))
+}
+
+func (e *ExpressionEnv) textStyle() TextStyle {
+	if e.style == nil {
+		style := e.Comment.Style()
+		e.style = &style
+	}
+
+	return *e.style
+}
@@ -0,0 +1,144 @@
+package entity
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/SevereCloud/vksdk/v2/object"
+)
+
+func TestHeuristicRulesCheckExpressions(t *testing.T) {
+	t.Parallel()
+
+	ivan := &object.UsersUser{ID: 1, FirstName: "Иван", LastName: "Иванов", Bdate: "2.3.1990"}
+	tests := []struct {
+		name       string
+		expression string
+		user       *object.UsersUser
+		text       string
+		history    CommentHistory
+		want       bool
+	}{
+		{
+			name:       "name and link",
+			expression: `(user.name == "Иван Иванов" or user.birth_date == "1.11.2000") and len(comment.links) > 0`,
+			user:       ivan,
+			text:       "Заходи на site.ru",
+			want:       true,
+		},
+		{
+			name:       "name without link",
+			expression: `(user.name == "Иван Иванов" or user.birth_date == "1.11.2000") and len(comment.links) > 0`,
+			user:       ivan,
+			text:       "Привет",
+			want:       false,
+		},
+		{
+			name:       "not",
+			expression: `not comment.from_community and comment.text contains "крипт"`,
+			user:       ivan,
+			text:       "Вложения в КРИПТУ",
+			want:       true,
+		},
+		{
+			name:       "history",
+			expression: `history.punished >= 2 and history.comments > 3`,
+			user:       ivan,
+			text:       "Привет",
+			history:    CommentHistory{Comments: 5, Punished: 2},
+			want:       true,
+		},
+		{
+			name:       "link host in list",
+			expression: `"bit.ly" in comment.links`,
+			user:       ivan,
+			text:       "https://bit.ly/abc",
+			want:       true,
+		},
+		{
+			name:       "community",
+			expression: `comment.words == 1 and history.comments == 0`,
+			text:       "Привет",
+			want:       true,
+		},
+		{
+			name:       "community has no user",
+			expression: `user.name == "" or user.name != "Иван Иванов" or user.age < 14`,
+			text:       "Привет",
+			want:       false,
+		},
+		{
+			name:       "hidden birth year",
+			expression: `user.age < 14 or user.age >= 14`,
+			user:       &object.UsersUser{ID: 1, FirstName: "Иван", LastName: "Иванов", Bdate: "2.3"},
+			text:       "Привет",
+			want:       false,
+		},
+		{
+			name:       "community is not an adult",
+			expression: `not (user.age >= 18) and comment.words == 1`,
+			text:       "Привет",
+			want:       false,
+		},
+		{
+			name:       "hidden birth year is not an adult",
+			expression: `not (user.age >= 18)`,
+			user:       &object.UsersUser{ID: 1, FirstName: "Иван", LastName: "Иванов", Bdate: "2.3"},
+			text:       "Привет",
+			want:       false,
+		},
+		{
+			name:       "no birth date",
+			expression: `user.age < 14`,
+			user:       &object.UsersUser{ID: 1, FirstName: "Иван", LastName: "Иванов"},
+			text:       "Привет",
+			want:       false,
+		},
+		{
+			name:       "known age",
+			expression: `user.age > 14`,
+			user:       ivan,
+			text:       "Привет",
+			want:       true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			rr := HeuristicRules{Expression: []HeuristicExpressionRule{{Expression: tt.expression}}}
+			if err := rr.CompileExpressions(); err != nil {
+				t.Fatalf("CompileExpressions() error = %v", err)
+			}
+
+			match, got := rr.CheckExpressions(tt.user, &Comment{Text: tt.text}, tt.history, nil)
+			if got != tt.want {
+				t.Fatalf("CheckExpressions() matched = %v, want %v", got, tt.want)
+			}
+			if got && !reflect.DeepEqual(match, exactMatch(BanReasonExpression)) {
+				t.Errorf("CheckExpressions() = %v, want %v", match, exactMatch(BanReasonExpression))
+			}
+		})
+	}
+}
+
+func TestHeuristicRules_ExpressionFields(t *testing.T) {
+	t.Parallel()
+
+	rr := HeuristicRules{Expression: []HeuristicExpressionRule{
+		{Expression: `user.age < 18 and user.city == "Москва"`},
+		{Expression: `user.deactivated == "banned"`},
+	}}
+	if err := rr.CompileExpressions(); err != nil {
+		t.Fatalf("CompileExpressions() error = %v", err)
+	}
+
+	if got, want := rr.UserFields(), []string{"bdate", "city"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("UserFields() = %v, want %v", got, want)
+	}
+	if !rr.ChecksDeactivated() {
+		t.Errorf("ChecksDeactivated() = false, want true")
+	}
+}
@@ -1,6 +1,18 @@
 package entity
 
-import "github.com/SevereCloud/vksdk/v2/object"
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/SevereCloud/vksdk/v2/object"
+	"github.com/sklyar/vk-banhammer/internal/bayes"
+	"github.com/sklyar/vk-banhammer/internal/langdetect"
+	"github.com/sklyar/vk-banhammer/internal/profanity"
+	"github.com/sklyar/vk-banhammer/internal/spampattern"
+	"github.com/sklyar/vk-banhammer/internal/textnorm"
+)
 
 // BanReason describes ban reason.
 type BanReason string
@@ -9,56 +21,1370 @@ type BanReason string
 const (
 	BanReasonNone           BanReason = "none"
 	BanReasonPersonNonGrata BanReason = "person_non_grata"
+	BanReasonCommunity      BanReason = "community"
+	BanReasonMentions       BanReason = "mentions"
+	BanReasonBlacklist      BanReason = "blacklist"
+	BanReasonFlood          BanReason = "flood"
+	BanReasonCopyPaste      BanReason = "copy_paste"
+	BanReasonLink           BanReason = "link"
+	BanReasonAttachment     BanReason = "attachment"
+	BanReasonProfanity      BanReason = "profanity"
+	BanReasonSpamPattern    BanReason = "spam_pattern"
+	BanReasonClassifier     BanReason = "classifier"
+	BanReasonLanguage       BanReason = "language"
+	BanReasonStyle          BanReason = "style"
+	BanReasonExpression     BanReason = "expression"
+
+	BanReasonPersonNonGrataFuzzy BanReason = "person_non_grata_fuzzy"
+	// BanReasonPersonNonGrataPossible is a match of full birth date on a user who hides birth year.
+	BanReasonPersonNonGrataPossible BanReason = "person_non_grata_possible"
+)
+
+// Punishment describes what is done with the author of matched comment.
+type Punishment string
+
+// Available punishments.
+const (
+	// PunishmentBan bans the author and deletes the comment.
+	PunishmentBan Punishment = "ban"
+	// PunishmentDelete deletes the comment only.
+	PunishmentDelete Punishment = "delete"
+	// PunishmentNone does nothing, the match is only logged.
+	PunishmentNone Punishment = "none"
 )
 
+// Match describes matched heuristic.
+type Match struct {
+	Reason     BanReason
+	Punishment Punishment
+	// Score is a similarity score of fuzzy match, it is 1 for exact matches.
+	Score float64
+	// Entities contains matched entities like phones or wallets, as "kind:value".
+	Entities []string
+}
+
+func exactMatch(reason BanReason) Match {
+	return Match{Reason: reason, Punishment: PunishmentBan, Score: 1}
+}
+
 // HeuristicRules describes heuristic rules.
 type HeuristicRules struct {
+	User           []HeuristicUserRule           `toml:"user"`
 	PersonNonGrata []HeuristicPersonNonGrataRule `toml:"person_non_grata"`
+	Community      []HeuristicCommunityRule      `toml:"community"`
+	Mention        []HeuristicMentionRule        `toml:"mention"`
+	Flood          []HeuristicFloodRule          `toml:"flood"`
+	CopyPaste      []HeuristicCopyPasteRule      `toml:"copy_paste"`
+	Link           []HeuristicLinkRule           `toml:"link"`
+	Attachment     []HeuristicAttachmentRule     `toml:"attachment"`
+	Profanity      []HeuristicProfanityRule      `toml:"profanity"`
+	SpamPattern    []HeuristicSpamPatternRule    `toml:"spam_pattern"`
+	Classifier     []HeuristicClassifierRule     `toml:"classifier"`
+	Language       []HeuristicLanguageRule       `toml:"language"`
+	Style          []HeuristicStyleRule          `toml:"style"`
+	Expression     []HeuristicExpressionRule     `toml:"expression"`
+
+	// Shorteners contains domains of link shorteners, links to them are resolved before checks.
+	// It is ["vk.cc"] by default.
+	Shorteners []string `toml:"shorteners"`
+	// ProfanityRoots replaces the built-in list of profanity roots.
+	// Roots ending with "*" match words starting with them, other roots match words with the same stem.
+	ProfanityRoots []string `toml:"profanity_roots"`
+	// ProfanityExceptions contains words which are never profane, in the same syntax as roots.
+	// They are added to the built-in exceptions unless ProfanityRoots are set.
+	ProfanityExceptions []string `toml:"profanity_exceptions"`
+	// ExemptCommunities contains IDs of partner communities which are never banned.
+	ExemptCommunities []int `toml:"exempt_communities"`
+	// Allowlist contains IDs of trusted users which are never banned.
+	Allowlist []int `toml:"allowlist"`
+
+	profanity *profanity.Detector
+}
+
+// BlacklistMatch returns match for blacklisted users.
+func BlacklistMatch() Match {
+	return exactMatch(BanReasonBlacklist)
 }
 
 // Check checks if user qualifies for heuristics.
-func (rr *HeuristicRules) Check(user *object.UsersUser) (BanReason, bool) {
+// Exact matches take precedence over fuzzy ones.
+// Evaluated rules are recorded in decision, if it is not nil.
+func (rr *HeuristicRules) Check(user *object.UsersUser, d *Decision) (Match, bool) {
+	var (
+		best  Match
+		found bool
+	)
+	values := userValues(user)
+	for i, r := range rr.PersonNonGrata {
+		m, ok := r.Match(user)
+		d.AddRule("person_non_grata", i, ok, m.Score, values)
+		if !ok {
+			continue
+		}
+		if m.Reason == BanReasonPersonNonGrata {
+			return m, true
+		}
+		if !found || m.Score > best.Score {
+			best, found = m, true
+		}
+	}
+
+	return best, found
+}
+
+// UserFields returns VK API user fields required to check the rules.
+// Base fields are always requested and go first.
+func (rr *HeuristicRules) UserFields(base ...string) []string {
+	fields := append([]string(nil), base...)
+	for _, r := range rr.PersonNonGrata {
+		for _, f := range r.Fields() {
+			if !containsFold(fields, f) {
+				fields = append(fields, f)
+			}
+		}
+	}
+
+	for _, r := range rr.Expression {
+		for _, f := range r.Fields() {
+			if !containsFold(fields, f) {
+				fields = append(fields, f)
+			}
+		}
+	}
+
+	return fields
+}
+
+// ChecksDeactivated reports whether any rule matches deactivated users.
+func (rr *HeuristicRules) ChecksDeactivated() bool {
 	for _, r := range rr.PersonNonGrata {
-		if r.Check(user) {
-			return BanReasonPersonNonGrata, true
+		if r.Deactivated != nil {
+			return true
+		}
+	}
+	for _, r := range rr.Expression {
+		if r.usesVar("user.deactivated") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// IsExemptCommunity checks if community is never banned.
+func (rr *HeuristicRules) IsExemptCommunity(groupID int) bool {
+	return containsInt(rr.ExemptCommunities, groupID)
+}
+
+// CheckCommunity checks if community qualifies for heuristics.
+func (rr *HeuristicRules) CheckCommunity(group *object.GroupsGroup, d *Decision) (Match, bool) {
+	if rr.IsExemptCommunity(group.ID) {
+		return Match{}, false
+	}
+
+	values := communityValues(group)
+	for i, r := range rr.Community {
+		matched := r.Check(group)
+		d.AddRule("community", i, matched, 0, values)
+		if matched {
+			return exactMatch(BanReasonCommunity), true
+		}
+	}
+
+	return Match{}, false
+}
+
+// CheckComment checks if comment qualifies for heuristics.
+// It is applied to comments of both users and communities.
+// Evaluated rules are recorded in decision, if it is not nil.
+func (rr *HeuristicRules) CheckComment(comment *Comment, d *Decision) (Match, bool) {
+	if len(rr.Mention) > 0 {
+		mentions := comment.Mentions()
+		values := mentionValues(mentions)
+		for i, r := range rr.Mention {
+			matched := r.Check(mentions)
+			d.AddRule("mention", i, matched, 0, values)
+			if matched {
+				return exactMatch(BanReasonMentions), true
+			}
+		}
+	}
+
+	if len(rr.Style) > 0 {
+		style := comment.Style()
+		values := styleValues(style)
+		for i, r := range rr.Style {
+			matched := r.Check(style)
+			d.AddRule("style", i, matched, 0, values)
+			if matched {
+				return r.Match(), true
+			}
+		}
+	}
+
+	if len(rr.Attachment) > 0 {
+		values := attachmentValues(comment.Attachments)
+		for i, r := range rr.Attachment {
+			matched := r.Check(comment)
+			d.AddRule("attachment", i, matched, 0, values)
+			if matched {
+				return r.Match(), true
+			}
+		}
+	}
+
+	if len(rr.Profanity) > 0 {
+		text := rr.checkProfanity(comment)
+		values := profanityValues(text)
+		for i, r := range rr.Profanity {
+			matched := r.Check(text)
+			d.AddRule("profanity", i, matched, 0, values)
+			if matched {
+				return r.Match(), true
+			}
+		}
+	}
+
+	if len(rr.SpamPattern) > 0 {
+		entities := comment.SpamEntities()
+		values := spamPatternValues(entities)
+		for i, r := range rr.SpamPattern {
+			matched := r.Check(entities)
+			d.AddRule("spam_pattern", i, len(matched) > 0, 0, values)
+			if len(matched) > 0 {
+				return r.Match(matched), true
+			}
+		}
+	}
+
+	if len(rr.Language) > 0 {
+		language := langdetect.Detect(comment.Text)
+		values := languageValues(language)
+		for i, r := range rr.Language {
+			matched := r.Check(language)
+			d.AddRule("language", i, matched, 0, values)
+			if matched {
+				return r.Match(), true
+			}
+		}
+	}
+
+	if len(rr.Classifier) > 0 {
+		tokens := bayes.Tokens(comment.NormalizedText())
+		values := map[string]any{"tokens": len(tokens)}
+		for i, r := range rr.Classifier {
+			p, matched := r.Check(tokens)
+			d.AddRule("classifier", i, matched, p, values)
+			if matched {
+				return r.Match(p), true
+			}
+		}
+	}
+
+	return Match{}, false
+}
+
+// CheckExpressions checks comment with expression rules.
+// User is nil for comments of communities, user variables are empty then.
+// Evaluated rules are recorded in decision with values of their variables, if it is not nil.
+func (rr *HeuristicRules) CheckExpressions(
+	user *object.UsersUser,
+	comment *Comment,
+	history CommentHistory,
+	d *Decision,
+) (Match, bool) {
+	if len(rr.Expression) == 0 {
+		return Match{}, false
+	}
+
+	env := &ExpressionEnv{User: user, Comment: comment, History: history, rules: rr}
+	for i, r := range rr.Expression {
+		matched := r.Check(env)
+		d.AddRule("expression", i, matched, 0, r.values(env))
+		if matched {
+			return r.Match(), true
+		}
+	}
+
+	return Match{}, false
+}
+
+// CompileExpressions compiles expressions of expression rules.
+func (rr *HeuristicRules) CompileExpressions() error {
+	for i := range rr.Expression {
+		if err := rr.Expression[i].Compile(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// LoadModels loads models of classifier rules.
+func (rr *HeuristicRules) LoadModels() error {
+	for i := range rr.Classifier {
+		if err := rr.Classifier[i].LoadModel(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// LoadProfanity builds profanity detector from profanity roots and exceptions.
+// Profanity is not found until it is called.
+func (rr *HeuristicRules) LoadProfanity() {
+	roots, exceptions := rr.ProfanityRoots, rr.ProfanityExceptions
+	if roots == nil {
+		roots = profanity.DefaultRoots
+		exceptions = append(append([]string(nil), profanity.DefaultExceptions...), exceptions...)
+	}
+	rr.profanity = profanity.New(roots, exceptions)
+}
+
+// checkProfanity finds profanity in comment text.
+func (rr *HeuristicRules) checkProfanity(comment *Comment) ProfanityCheck {
+	if rr.profanity == nil {
+		return ProfanityCheck{MixedScript: profanity.MixedScriptWords(comment.Text)}
+	}
+
+	return ProfanityCheck{
+		Words:       rr.profanity.Find(strings.Fields(comment.NormalizedText())),
+		PlainWords:  rr.profanity.Find(strings.FieldsFunc(strings.ToLower(comment.Text), isNotWordRune)),
+		MixedScript: profanity.MixedScriptWords(comment.Text),
+	}
+}
+
+// IsShortener checks if host is a link shortener.
+func (rr *HeuristicRules) IsShortener(host string) bool {
+	if rr.Shorteners == nil {
+		return matchAnyDomain(defaultShorteners, host)
+	}
+
+	return matchAnyDomain(rr.Shorteners, host)
+}
+
+// CheckLinks checks if hosts of comment links qualify for heuristics.
+// Account age of the author is requested only when a rule needs it,
+// it is not known for communities.
+// Evaluated rules are recorded in decision, if it is not nil.
+func (rr *HeuristicRules) CheckLinks(
+	hosts []string,
+	accountAge func() (time.Duration, error),
+	d *Decision,
+) (Match, bool) {
+	if len(hosts) == 0 {
+		return Match{}, false
+	}
+
+	// Account age is requested once and only if a rule needs it.
+	var (
+		age       time.Duration
+		ageErr    error
+		requested bool
+	)
+	cachedAge := func() (time.Duration, bool) {
+		if !requested {
+			age, ageErr = accountAge()
+			requested = true
+		}
+		return age, ageErr == nil
+	}
+
+	for i, r := range rr.Link {
+		matched := r.Check(hosts, cachedAge)
+		values := map[string]any{"hosts": hosts}
+		switch {
+		case requested && ageErr == nil:
+			values["account_age"] = age.String()
+		case requested:
+			// Failed lookup does not make the account young, the error tells it apart from an old account.
+			values["account_age_error"] = ageErr.Error()
+		}
+		d.AddRule("link", i, matched, 0, values)
+		if matched {
+			return r.Match(), true
+		}
+	}
+
+	return Match{}, false
+}
+
+// Sex values used in person non grata rules.
+const (
+	SexFemale = "female"
+	SexMale   = "male"
+)
+
+// vkSex maps sex values used in rules to VK API values.
+var vkSex = map[string]int{
+	SexFemale: 1,
+	SexMale:   2,
+}
+
+// HeuristicUserRule describes explicitly blacklisted account.
+// Either ID or ScreenName is set, communities have negative IDs.
+type HeuristicUserRule struct {
+	ID         *int    `toml:"id"`
+	ScreenName *string `toml:"screen_name"`
+}
+
+// HeuristicFloodRule describes rule for users posting too many comments.
+// It matches when the author posts more than MaxComments comments in Window.
+type HeuristicFloodRule struct {
+	MaxComments int           `toml:"max_comments"`
+	Window      time.Duration `toml:"window"`
+	// PerPost makes comments counted per post instead of across all posts.
+	PerPost bool `toml:"per_post"`
+	// Action is a punishment for flood, it is "ban" by default.
+	Action Punishment `toml:"action"`
+}
+
+// Match returns match for the flood rule.
+func (r HeuristicFloodRule) Match() Match {
+	m := exactMatch(BanReasonFlood)
+	if r.Action != "" {
+		m.Punishment = r.Action
+	}
+
+	return m
+}
+
+const (
+	defaultCopyPasteMaxDistance = 3
+	defaultCopyPasteMinWords    = 3
+)
+
+// HeuristicCopyPasteRule describes rule for the same text posted from many accounts.
+// It matches once MinAuthors distinct authors have posted near-identical text in Window.
+type HeuristicCopyPasteRule struct {
+	MinAuthors int           `toml:"min_authors"`
+	Window     time.Duration `toml:"window"`
+	// MaxDistance is a maximum Hamming distance between simhashes of near-identical texts, 3 by default.
+	MaxDistance *int `toml:"max_distance"`
+	// MinWords is a minimum number of words in checked text, 3 by default.
+	// Short texts like greetings are often the same by coincidence.
+	MinWords *int `toml:"min_words"`
+	// Cleanup makes earlier copies of the text deleted too.
+	Cleanup bool `toml:"cleanup"`
+	// Action is a punishment for copy-paste, it is "ban" by default.
+	Action Punishment `toml:"action"`
+}
+
+// Match returns match for the copy-paste rule.
+func (r HeuristicCopyPasteRule) Match() Match {
+	m := exactMatch(BanReasonCopyPaste)
+	if r.Action != "" {
+		m.Punishment = r.Action
+	}
+
+	return m
+}
+
+// Distance returns maximum Hamming distance between near-identical texts.
+func (r HeuristicCopyPasteRule) Distance() int {
+	if r.MaxDistance == nil {
+		return defaultCopyPasteMaxDistance
+	}
+
+	return *r.MaxDistance
+}
+
+// Words returns minimum number of words in checked text.
+func (r HeuristicCopyPasteRule) Words() int {
+	if r.MinWords == nil {
+		return defaultCopyPasteMinWords
+	}
+
+	return *r.MinWords
+}
+
+// defaultShorteners is a list of link shorteners resolved by default.
+var defaultShorteners = []string{"vk.cc"}
+
+// HeuristicLinkRule describes rule for comments with links.
+// Domain patterns support wildcards, "*.example.com" matches subdomains of example.com.
+type HeuristicLinkRule struct {
+	// DenyDomains makes the rule match only links to these domains.
+	DenyDomains []string `toml:"deny_domains"`
+	// AllowDomains contains domains which are ignored by the rule.
+	AllowDomains []string `toml:"allow_domains"`
+	// MaxAccountAge makes the rule match only links from accounts younger than it.
+	MaxAccountAge time.Duration `toml:"max_account_age"`
+	// Action is a punishment for the link, it is "ban" by default.
+	Action Punishment `toml:"action"`
+}
+
+// Check checks if any of link hosts qualifies for the rule.
+// A rule without deny domains and account age matches any link which is not allowed.
+func (r HeuristicLinkRule) Check(hosts []string, accountAge func() (time.Duration, bool)) bool {
+	var linked, denied bool
+	for _, host := range hosts {
+		if host == "" || matchAnyDomain(r.AllowDomains, host) {
+			continue
 		}
+		linked = true
+		denied = denied || matchAnyDomain(r.DenyDomains, host)
+	}
+	if !linked || len(r.DenyDomains) > 0 && !denied {
+		return false
 	}
 
-	return BanReasonNone, false
+	if r.MaxAccountAge > 0 {
+		age, ok := accountAge()
+		return ok && age < r.MaxAccountAge
+	}
+
+	return true
+}
+
+// Match returns match for the link rule.
+func (r HeuristicLinkRule) Match() Match {
+	m := exactMatch(BanReasonLink)
+	if r.Action != "" {
+		m.Punishment = r.Action
+	}
+
+	return m
 }
 
 // HeuristicPersonNonGrataRule describes person non grata rule.
 type HeuristicPersonNonGrataRule struct {
-	Name      *string `toml:"name"`
+	Name *string `toml:"name"`
+	// NameSimilarity enables fuzzy name matching, names with similarity
+	// not less than the threshold match. Similarity is from 0 to 1.
+	NameSimilarity *float64 `toml:"name_similarity"`
+	// FuzzyAction is a punishment for fuzzy name matches and possible birth date matches,
+	// it is "ban" by default.
+	FuzzyAction Punishment `toml:"fuzzy_action"`
+	// BirthDate is either "D.M.YYYY" or "D.M", the latter matches any year.
 	BirthDate *string `toml:"birth_date"`
+	// MatchHiddenYear makes full BirthDate possibly match users who hide birth year
+	// but have the same day and month. Such matches are not exact and take FuzzyAction.
+	MatchHiddenYear bool `toml:"match_hidden_year"`
+	// Age and birth year bounds are inclusive, they never match users who hide birth year.
+	MinAge       *int `toml:"min_age"`
+	MaxAge       *int `toml:"max_age"`
+	MinBirthYear *int `toml:"min_birth_year"`
+	MaxBirthYear *int `toml:"max_birth_year"`
+
+	MaidenName *string `toml:"maiden_name"`
+	Nickname   *string `toml:"nickname"`
+	Domain     *string `toml:"domain"`
+	// City and Country are matched by title case-insensitively.
+	City    *string `toml:"city"`
+	Country *string `toml:"country"`
+	// Sex is either "female" or "male".
+	Sex *string `toml:"sex"`
+	// Status and Site match if they contain the value case-insensitively.
+	Status      *string `toml:"status"`
+	Site        *string `toml:"site"`
+	HasPhoto    *bool   `toml:"has_photo"`
+	IsClosed    *bool   `toml:"is_closed"`
+	Deactivated *string `toml:"deactivated"`
+
+	MinFollowersCount *int `toml:"min_followers_count"`
+	MaxFollowersCount *int `toml:"max_followers_count"`
 }
 
 // Check checks if user qualifies for rule.
 func (r HeuristicPersonNonGrataRule) Check(user *object.UsersUser) bool {
+	_, ok := r.Match(user)
+	return ok
+}
+
+// Match checks if user qualifies for rule and describes the match.
+func (r HeuristicPersonNonGrataRule) Match(user *object.UsersUser) (Match, bool) {
 	matches := 0
+	match := exactMatch(BanReasonPersonNonGrata)
 
 	if r.Name != nil {
 		name := user.FirstName + " " + user.LastName
-		if name == *r.Name {
+		if textnorm.Normalize(name) == textnorm.Normalize(*r.Name) {
+			matches++
+		} else if r.NameSimilarity != nil {
+			if score := NameSimilarity(name, *r.Name); score >= *r.NameSimilarity {
+				matches++
+				match = Match{
+					Reason:     BanReasonPersonNonGrataFuzzy,
+					Punishment: r.fuzzyAction(),
+					Score:      score,
+				}
+			}
+		}
+	}
+	if r.BirthDate != nil {
+		if ok, possible := r.matchBirthDate(user.Bdate); ok {
+			matches++
+			if possible && match.Reason == BanReasonPersonNonGrata {
+				match.Reason = BanReasonPersonNonGrataPossible
+				match.Punishment = r.fuzzyAction()
+			}
+		}
+	}
+	if bdate, ok := ParseBirthDate(user.Bdate); ok && bdate.HasYear() {
+		age := bdate.Age(time.Now())
+		if r.MinAge != nil && age >= *r.MinAge {
+			matches++
+		}
+		if r.MaxAge != nil && age <= *r.MaxAge {
+			matches++
+		}
+		if r.MinBirthYear != nil && bdate.Year >= *r.MinBirthYear {
+			matches++
+		}
+		if r.MaxBirthYear != nil && bdate.Year <= *r.MaxBirthYear {
 			matches++
 		}
 	}
-	if r.BirthDate != nil && user.Bdate == *r.BirthDate {
+	if r.MaidenName != nil && textnorm.Normalize(user.MaidenName) == textnorm.Normalize(*r.MaidenName) {
+		matches++
+	}
+	if r.Nickname != nil && textnorm.Normalize(user.Nickname) == textnorm.Normalize(*r.Nickname) {
+		matches++
+	}
+	if r.Domain != nil && strings.EqualFold(user.Domain, *r.Domain) {
+		matches++
+	}
+	if r.City != nil && strings.EqualFold(user.City.Title, *r.City) {
+		matches++
+	}
+	if r.Country != nil && strings.EqualFold(user.Country.Title, *r.Country) {
+		matches++
+	}
+	if r.Sex != nil && user.Sex == vkSex[*r.Sex] {
+		matches++
+	}
+	if r.Status != nil && strings.Contains(textnorm.Normalize(user.Status), textnorm.Normalize(*r.Status)) {
+		matches++
+	}
+	if r.Site != nil && containsFoldString(user.Site, *r.Site) {
+		matches++
+	}
+	if r.HasPhoto != nil && bool(user.HasPhoto) == *r.HasPhoto {
+		matches++
+	}
+	if r.IsClosed != nil && bool(user.IsClosed) == *r.IsClosed {
+		matches++
+	}
+	if r.Deactivated != nil && user.Deactivated == *r.Deactivated {
+		matches++
+	}
+	if r.MinFollowersCount != nil && user.FollowersCount >= *r.MinFollowersCount {
+		matches++
+	}
+	if r.MaxFollowersCount != nil && user.FollowersCount <= *r.MaxFollowersCount {
 		matches++
 	}
 
-	return matches == r.assertCount()
+	if matches != r.assertCount() {
+		return Match{}, false
+	}
+
+	return match, true
+}
+
+func (r HeuristicPersonNonGrataRule) fuzzyAction() Punishment {
+	if r.FuzzyAction == "" {
+		return PunishmentBan
+	}
+	return r.FuzzyAction
+}
+
+// matchBirthDate checks birth date, possible is set when the user hides birth year.
+func (r HeuristicPersonNonGrataRule) matchBirthDate(bdate string) (ok, possible bool) {
+	want, ok := ParseBirthDate(*r.BirthDate)
+	if !ok {
+		return false, false
+	}
+	got, ok := ParseBirthDate(bdate)
+	if !ok || !got.SameDay(want) {
+		return false, false
+	}
+
+	switch {
+	case !want.HasYear():
+		return true, false
+	case !got.HasYear():
+		return r.MatchHiddenYear, r.MatchHiddenYear
+	default:
+		return got.Year == want.Year, false
+	}
+}
+
+// Fields returns VK API user fields required to check the rule.
+func (r HeuristicPersonNonGrataRule) Fields() []string {
+	var fields []string
+
+	if r.BirthDate != nil || r.MinAge != nil || r.MaxAge != nil || r.MinBirthYear != nil || r.MaxBirthYear != nil {
+		fields = append(fields, "bdate")
+	}
+	if r.MaidenName != nil {
+		fields = append(fields, "maiden_name")
+	}
+	if r.Nickname != nil {
+		fields = append(fields, "nickname")
+	}
+	if r.Domain != nil {
+		fields = append(fields, "domain")
+	}
+	if r.City != nil {
+		fields = append(fields, "city")
+	}
+	if r.Country != nil {
+		fields = append(fields, "country")
+	}
+	if r.Sex != nil {
+		fields = append(fields, "sex")
+	}
+	if r.Status != nil {
+		fields = append(fields, "status")
+	}
+	if r.Site != nil {
+		fields = append(fields, "site")
+	}
+	if r.HasPhoto != nil {
+		fields = append(fields, "has_photo")
+	}
+	if r.MinFollowersCount != nil || r.MaxFollowersCount != nil {
+		fields = append(fields, "followers_count")
+	}
+
+	// Name, is_closed and deactivated are always returned.
+	return fields
 }
 
 func (r HeuristicPersonNonGrataRule) assertCount() int {
 	count := 0
 
+	for _, set := range []bool{
+		r.Name != nil,
+		r.BirthDate != nil,
+		r.MinAge != nil,
+		r.MaxAge != nil,
+		r.MinBirthYear != nil,
+		r.MaxBirthYear != nil,
+		r.MaidenName != nil,
+		r.Nickname != nil,
+		r.Domain != nil,
+		r.City != nil,
+		r.Country != nil,
+		r.Sex != nil,
+		r.Status != nil,
+		r.Site != nil,
+		r.HasPhoto != nil,
+		r.IsClosed != nil,
+		r.Deactivated != nil,
+		r.MinFollowersCount != nil,
+		r.MaxFollowersCount != nil,
+	} {
+		if set {
+			count++
+		}
+	}
+
+	return count
+}
+
+// HeuristicCommunityRule describes rule for comments authored by communities.
+type HeuristicCommunityRule struct {
+	Name            *string `toml:"name"`
+	ScreenName      *string `toml:"screen_name"`
+	Verified        *bool   `toml:"verified"`
+	MinMembersCount *int    `toml:"min_members_count"`
+	MaxMembersCount *int    `toml:"max_members_count"`
+}
+
+// Check checks if community qualifies for rule.
+func (r HeuristicCommunityRule) Check(group *object.GroupsGroup) bool {
+	matches := 0
+
+	if r.Name != nil && group.Name == *r.Name {
+		matches++
+	}
+	if r.ScreenName != nil && group.ScreenName == *r.ScreenName {
+		matches++
+	}
+	if r.Verified != nil && bool(group.Verified) == *r.Verified {
+		matches++
+	}
+	if r.MinMembersCount != nil && group.MembersCount >= *r.MinMembersCount {
+		matches++
+	}
+	if r.MaxMembersCount != nil && group.MembersCount <= *r.MaxMembersCount {
+		matches++
+	}
+
+	return matches == r.assertCount()
+}
+
+func (r HeuristicCommunityRule) assertCount() int {
+	count := 0
+
 	if r.Name != nil {
 		count++
 	}
-	if r.BirthDate != nil {
+	if r.ScreenName != nil {
+		count++
+	}
+	if r.Verified != nil {
+		count++
+	}
+	if r.MinMembersCount != nil {
+		count++
+	}
+	if r.MaxMembersCount != nil {
 		count++
 	}
 
 	return count
 }
+
+// HeuristicMentionRule describes rule for mentions in comment text.
+type HeuristicMentionRule struct {
+	// MaxUsers is a maximum number of mentioned users, comment with more mentions matches.
+	MaxUsers *int `toml:"max_users"`
+	// MaxCommunities is a maximum number of mentioned communities, comment with more mentions matches.
+	MaxCommunities *int `toml:"max_communities"`
+	// Communities contains IDs of blacklisted communities.
+	Communities []int `toml:"communities"`
+	// ScreenNames contains blacklisted screen names, like "durov" or "club1".
+	ScreenNames []string `toml:"screen_names"`
+}
+
+// Check checks if mentions qualify for rule.
+func (r HeuristicMentionRule) Check(mentions []Mention) bool {
+	matches := 0
+
+	if r.MaxUsers != nil && countMentions(mentions, MentionTypeUser) > *r.MaxUsers {
+		matches++
+	}
+	if r.MaxCommunities != nil && countMentions(mentions, MentionTypeCommunity) > *r.MaxCommunities {
+		matches++
+	}
+	if len(r.Communities) > 0 && mentionsAny(mentions, func(m Mention) bool {
+		return m.Type == MentionTypeCommunity && containsInt(r.Communities, m.ID)
+	}) {
+		matches++
+	}
+	if len(r.ScreenNames) > 0 && mentionsAny(mentions, func(m Mention) bool {
+		return containsFold(r.ScreenNames, m.ScreenName)
+	}) {
+		matches++
+	}
+
+	return matches == r.assertCount()
+}
+
+func (r HeuristicMentionRule) assertCount() int {
+	count := 0
+
+	if r.MaxUsers != nil {
+		count++
+	}
+	if r.MaxCommunities != nil {
+		count++
+	}
+	if len(r.Communities) > 0 {
+		count++
+	}
+	if len(r.ScreenNames) > 0 {
+		count++
+	}
+
+	return count
+}
+
+// HeuristicAttachmentRule describes rule for comment attachments.
+// Conditions of the rule are combined with AND.
+type HeuristicAttachmentRule struct {
+	// Types contains attachment types like "photo", "sticker" or "doc", comment with any of them matches.
+	Types []string `toml:"types"`
+	// MinCount is a minimum number of attachments, only attachments of Types are counted if they are set.
+	MinCount *int `toml:"min_count"`
+	// BlankText makes the rule match only comments with or without text.
+	BlankText *bool `toml:"blank_text"`
+	// LinkURLs contains substrings of blacklisted URLs of attached links.
+	LinkURLs []string `toml:"link_urls"`
+	// DocExtensions contains blacklisted extensions of attached documents, like "apk".
+	DocExtensions []string `toml:"doc_extensions"`
+	// StickerIDs contains IDs of blacklisted stickers.
+	StickerIDs []int `toml:"sticker_ids"`
+	// Action is a punishment for the attachment, it is "ban" by default.
+	Action Punishment `toml:"action"`
+}
+
+// Check checks if comment attachments qualify for rule.
+func (r HeuristicAttachmentRule) Check(comment *Comment) bool {
+	if len(comment.Attachments) == 0 {
+		return false
+	}
+
+	matches := 0
+
+	var typed int
+	for _, a := range comment.Attachments {
+		if len(r.Types) == 0 || containsFold(r.Types, a.Type) {
+			typed++
+		}
+	}
+	if len(r.Types) > 0 && typed > 0 {
+		matches++
+	}
+	if r.MinCount != nil && typed >= *r.MinCount {
+		matches++
+	}
+	if r.BlankText != nil && *r.BlankText == (strings.TrimSpace(comment.Text) == "") {
+		matches++
+	}
+	if len(r.LinkURLs) > 0 && attachmentsAny(comment.Attachments, func(a Attachment) bool {
+		return a.Link != nil && containsAnyFoldString(a.Link.URL, r.LinkURLs)
+	}) {
+		matches++
+	}
+	if len(r.DocExtensions) > 0 && attachmentsAny(comment.Attachments, func(a Attachment) bool {
+		return a.Doc != nil && containsFold(r.DocExtensions, strings.TrimPrefix(a.Doc.Ext, "."))
+	}) {
+		matches++
+	}
+	if len(r.StickerIDs) > 0 && attachmentsAny(comment.Attachments, func(a Attachment) bool {
+		return a.Sticker != nil && containsInt(r.StickerIDs, a.Sticker.StickerID)
+	}) {
+		matches++
+	}
+
+	return matches == r.assertCount()
+}
+
+// Match returns match for the attachment rule.
+func (r HeuristicAttachmentRule) Match() Match {
+	m := exactMatch(BanReasonAttachment)
+	if r.Action != "" {
+		m.Punishment = r.Action
+	}
+
+	return m
+}
+
+func (r HeuristicAttachmentRule) assertCount() int {
+	count := 0
+
+	if len(r.Types) > 0 {
+		count++
+	}
+	if r.MinCount != nil {
+		count++
+	}
+	if r.BlankText != nil {
+		count++
+	}
+	if len(r.LinkURLs) > 0 {
+		count++
+	}
+	if len(r.DocExtensions) > 0 {
+		count++
+	}
+	if len(r.StickerIDs) > 0 {
+		count++
+	}
+
+	return count
+}
+
+// ProfanityCheck describes profanity found in comment text.
+type ProfanityCheck struct {
+	// Words contains profane words of normalized text.
+	Words []string
+	// PlainWords contains profane words found without normalization.
+	PlainWords []string
+	// MixedScript is a number of words mixing Cyrillic and Latin letters.
+	MixedScript int
+}
+
+// Obfuscated reports whether some profanity is found only in normalized text.
+func (c ProfanityCheck) Obfuscated() bool {
+	return len(c.Words) > len(c.PlainWords)
+}
+
+// HeuristicProfanityRule describes rule for profanity and insults in comment text.
+// Conditions of the rule are combined with AND.
+type HeuristicProfanityRule struct {
+	// MinCount is a minimum number of profane words, 1 by default.
+	// A rule with only MaxMixedScriptWords does not require profanity.
+	MinCount *int `toml:"min_count"`
+	// Obfuscated makes the rule match only profanity hidden with homoglyphs, leetspeak or spacing.
+	Obfuscated *bool `toml:"obfuscated"`
+	// MaxMixedScriptWords is a maximum number of words mixing Cyrillic and Latin letters,
+	// comment with more such words matches.
+	MaxMixedScriptWords *int `toml:"max_mixed_script_words"`
+	// Action is a punishment for profanity, it is "ban" by default.
+	Action Punishment `toml:"action"`
+}
+
+// Check checks if profanity qualifies for rule.
+func (r HeuristicProfanityRule) Check(c ProfanityCheck) bool {
+	matches := 0
+
+	if r.checksCount() && len(c.Words) >= r.minCount() {
+		matches++
+	}
+	if r.Obfuscated != nil && c.Obfuscated() == *r.Obfuscated {
+		matches++
+	}
+	if r.MaxMixedScriptWords != nil && c.MixedScript > *r.MaxMixedScriptWords {
+		matches++
+	}
+
+	return matches == r.assertCount()
+}
+
+// Match returns match for the profanity rule.
+func (r HeuristicProfanityRule) Match() Match {
+	m := exactMatch(BanReasonProfanity)
+	if r.Action != "" {
+		m.Punishment = r.Action
+	}
+
+	return m
+}
+
+func (r HeuristicProfanityRule) checksCount() bool {
+	return r.MinCount != nil || r.MaxMixedScriptWords == nil
+}
+
+func (r HeuristicProfanityRule) minCount() int {
+	if r.MinCount == nil {
+		return 1
+	}
+
+	return *r.MinCount
+}
+
+func (r HeuristicProfanityRule) assertCount() int {
+	count := 0
+
+	if r.checksCount() {
+		count++
+	}
+	if r.Obfuscated != nil {
+		count++
+	}
+	if r.MaxMixedScriptWords != nil {
+		count++
+	}
+
+	return count
+}
+
+// HeuristicSpamPatternRule describes rule for phones, messenger handles, wallets and card numbers.
+type HeuristicSpamPatternRule struct {
+	// Kinds contains kinds of entities: "phone", "card", "telegram", "whatsapp" or "wallet".
+	Kinds []string `toml:"kinds"`
+	// MinCount is a minimum number of entities of the kinds, 1 by default.
+	MinCount *int `toml:"min_count"`
+	// Action is a punishment for the entities, it is "ban" by default.
+	Action Punishment `toml:"action"`
+}
+
+// Check returns entities matched by the rule, it is empty if the rule does not match.
+func (r HeuristicSpamPatternRule) Check(entities []spampattern.Entity) []spampattern.Entity {
+	var matched []spampattern.Entity
+	for _, e := range entities {
+		if containsFold(r.Kinds, string(e.Kind)) {
+			matched = append(matched, e)
+		}
+	}
+
+	minCount := 1
+	if r.MinCount != nil {
+		minCount = *r.MinCount
+	}
+	if len(matched) < minCount {
+		return nil
+	}
+
+	return matched
+}
+
+// Match returns match for the spam pattern rule with matched entities.
+func (r HeuristicSpamPatternRule) Match(entities []spampattern.Entity) Match {
+	m := exactMatch(BanReasonSpamPattern)
+	if r.Action != "" {
+		m.Punishment = r.Action
+	}
+	for _, e := range entities {
+		m.Entities = append(m.Entities, e.String())
+	}
+
+	return m
+}
+
+// HeuristicStyleRule describes rule for comment text style, conditions are combined with AND.
+type HeuristicStyleRule struct {
+	// MinLength is a minimum number of characters without spaces.
+	MinLength *int `toml:"min_length"`
+	// MinCapsRatio is a minimum share of uppercase letters, from 0 to 1.
+	MinCapsRatio *float64 `toml:"min_caps_ratio"`
+	// MinEmojiRatio is a minimum share of emoji among characters, from 0 to 1.
+	MinEmojiRatio *float64 `toml:"min_emoji_ratio"`
+	// MinRepeatRun is a minimum length of a run of the same character, like "!!!!!" or "ааааа".
+	MinRepeatRun *int `toml:"min_repeat_run"`
+	// MinLinkDensity is a minimum number of links per word.
+	MinLinkDensity *float64 `toml:"min_link_density"`
+	// MinMentionDensity is a minimum number of mentions per word.
+	MinMentionDensity *float64 `toml:"min_mention_density"`
+	// Action is a punishment for the comment, it is "ban" by default.
+	Action Punishment `toml:"action"`
+}
+
+// Check checks if comment text style qualifies for heuristics.
+func (r HeuristicStyleRule) Check(style TextStyle) bool {
+	matches := 0
+
+	if r.MinLength != nil && style.Length >= *r.MinLength {
+		matches++
+	}
+	if r.MinCapsRatio != nil && style.Letters > 0 && style.CapsRatio() >= *r.MinCapsRatio {
+		matches++
+	}
+	if r.MinEmojiRatio != nil && style.Emoji > 0 && style.EmojiRatio() >= *r.MinEmojiRatio {
+		matches++
+	}
+	if r.MinRepeatRun != nil && style.MaxRepeat >= *r.MinRepeatRun {
+		matches++
+	}
+	if r.MinLinkDensity != nil && style.Links > 0 && style.LinkDensity() >= *r.MinLinkDensity {
+		matches++
+	}
+	if r.MinMentionDensity != nil && style.Mentions > 0 && style.MentionDensity() >= *r.MinMentionDensity {
+		matches++
+	}
+
+	return matches == r.assertCount()
+}
+
+func (r HeuristicStyleRule) assertCount() int {
+	count := 0
+
+	if r.MinLength != nil {
+		count++
+	}
+	if r.MinCapsRatio != nil {
+		count++
+	}
+	if r.MinEmojiRatio != nil {
+		count++
+	}
+	if r.MinRepeatRun != nil {
+		count++
+	}
+	if r.MinLinkDensity != nil {
+		count++
+	}
+	if r.MinMentionDensity != nil {
+		count++
+	}
+
+	return count
+}
+
+// Match returns match for the style rule.
+func (r HeuristicStyleRule) Match() Match {
+	m := exactMatch(BanReasonStyle)
+	if r.Action != "" {
+		m.Punishment = r.Action
+	}
+
+	return m
+}
+
+// HeuristicLanguageRule describes rule for comments in foreign languages and scripts.
+type HeuristicLanguageRule struct {
+	// Languages contains allowed ISO 639-1 language codes, comments in other languages are matched.
+	// Comments in unknown language are never matched.
+	Languages []string `toml:"languages"`
+	// MaxNonCyrillic is a maximum share of non-Cyrillic letters, from 0 to 1.
+	MaxNonCyrillic *float64 `toml:"max_non_cyrillic"`
+	// MinLetters is a minimum number of letters, 20 by default.
+	// Language of short comments can not be detected reliably, they are never matched.
+	MinLetters *int `toml:"min_letters"`
+	// Action is a punishment for the comment, it is "ban" by default.
+	Action Punishment `toml:"action"`
+}
+
+// Check checks if detected language of comment qualifies for heuristics.
+func (r HeuristicLanguageRule) Check(language langdetect.Result) bool {
+	if language.Letters < r.minLetters() {
+		return false
+	}
+
+	matches := 0
+
+	if len(r.Languages) > 0 && language.Language != "" && !containsFold(r.Languages, language.Language) {
+		matches++
+	}
+	if r.MaxNonCyrillic != nil && 1-language.Share(langdetect.ScriptCyrillic) > *r.MaxNonCyrillic {
+		matches++
+	}
+
+	return matches == r.assertCount()
+}
+
+func (r HeuristicLanguageRule) minLetters() int {
+	if r.MinLetters == nil {
+		return 20
+	}
+
+	return *r.MinLetters
+}
+
+func (r HeuristicLanguageRule) assertCount() int {
+	count := 0
+
+	if len(r.Languages) > 0 {
+		count++
+	}
+	if r.MaxNonCyrillic != nil {
+		count++
+	}
+
+	return count
+}
+
+// Match returns match for the language rule.
+func (r HeuristicLanguageRule) Match() Match {
+	m := exactMatch(BanReasonLanguage)
+	if r.Action != "" {
+		m.Punishment = r.Action
+	}
+
+	return m
+}
+
+// HeuristicClassifierRule describes rule for comments classified as spam by a naive Bayes model.
+type HeuristicClassifierRule struct {
+	// Model is a path to the model file written by "banhammer train".
+	Model string `toml:"model"`
+	// Threshold is a minimum spam probability, from 0 to 1.
+	Threshold float64 `toml:"threshold"`
+	// MinTokens is a minimum number of tokens known to the model, 3 by default.
+	// Short comments have too few tokens to be classified reliably.
+	MinTokens *int `toml:"min_tokens"`
+	// Action is a punishment for spam, it is "ban" by default.
+	Action Punishment `toml:"action"`
+
+	model *bayes.Model
+}
+
+// LoadModel loads the model file of the rule.
+func (r *HeuristicClassifierRule) LoadModel() error {
+	m, err := bayes.Load(r.Model)
+	if err != nil {
+		return fmt.Errorf("failed to load model: %w", err)
+	}
+	r.model = m
+
+	return nil
+}
+
+// Check returns spam probability of comment tokens and whether it reaches the threshold.
+func (r HeuristicClassifierRule) Check(tokens []string) (float64, bool) {
+	if r.model == nil {
+		return 0, false
+	}
+
+	p, known := r.model.SpamProbability(tokens)
+	if known < r.minTokens() {
+		return p, false
+	}
+
+	return p, p >= r.Threshold
+}
+
+func (r HeuristicClassifierRule) minTokens() int {
+	if r.MinTokens == nil {
+		return 3
+	}
+
+	return *r.MinTokens
+}
+
+// Match returns match for the classifier rule, its score is the spam probability.
+func (r HeuristicClassifierRule) Match(probability float64) Match {
+	m := exactMatch(BanReasonClassifier)
+	m.Score = probability
+	if r.Action != "" {
+		m.Punishment = r.Action
+	}
+
+	return m
+}
+
+func isNotWordRune(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+}
+
+func attachmentsAny(attachments []Attachment, fn func(Attachment) bool) bool {
+	for _, a := range attachments {
+		if fn(a) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func countMentions(mentions []Mention, typ MentionType) int {
+	count := 0
+	for _, m := range mentions {
+		if m.Type == typ {
+			count++
+		}
+	}
+
+	return count
+}
+
+func mentionsAny(mentions []Mention, fn func(Mention) bool) bool {
+	for _, m := range mentions {
+		if fn(m) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func containsInt(values []int, v int) bool {
+	for _, value := range values {
+		if value == v {
+			return true
+		}
+	}
+
+	return false
+}
+
+func containsFold(values []string, v string) bool {
+	for _, value := range values {
+		if strings.EqualFold(value, v) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func containsFoldString(s, substr string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+}
+
+func containsAnyFoldString(s string, substrs []string) bool {
+	for _, substr := range substrs {
+		if containsFoldString(s, substr) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,600 @@
+package entity
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/SevereCloud/vksdk/v2/object"
+	"github.com/sklyar/vk-banhammer/internal/bayes"
+	"github.com/sklyar/vk-banhammer/internal/langdetect"
+	"github.com/sklyar/vk-banhammer/internal/textnorm"
+)
+
+func TestHeuristicPersonNonGrataRuleCheck_BirthDate(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	// Birthday has passed this year for anyone born on the 1st of January.
+	tenYearsOld := "1.1." + strconv.Itoa(now.Year()-10)
+
+	tests := []struct {
+		name  string
+		rule  HeuristicPersonNonGrataRule
+		bdate string
+		want  bool
+	}{
+		{
+			name:  "full date matches",
+			rule:  HeuristicPersonNonGrataRule{BirthDate: toPtr("19.9.1921")},
+			bdate: "19.9.1921",
+			want:  true,
+		},
+		{
+			name:  "full date does not match other year",
+			rule:  HeuristicPersonNonGrataRule{BirthDate: toPtr("19.9.1921")},
+			bdate: "19.9.1922",
+			want:  false,
+		},
+		{
+			name:  "day and month match full date",
+			rule:  HeuristicPersonNonGrataRule{BirthDate: toPtr("19.9")},
+			bdate: "19.9.1921",
+			want:  true,
+		},
+		{
+			name:  "day and month match hidden year",
+			rule:  HeuristicPersonNonGrataRule{BirthDate: toPtr("19.9")},
+			bdate: "19.9",
+			want:  true,
+		},
+		{
+			name:  "full date does not match hidden year by default",
+			rule:  HeuristicPersonNonGrataRule{BirthDate: toPtr("19.9.1921")},
+			bdate: "19.9",
+			want:  false,
+		},
+		{
+			name:  "full date possibly matches hidden year",
+			rule:  HeuristicPersonNonGrataRule{BirthDate: toPtr("19.9.1921"), MatchHiddenYear: true},
+			bdate: "19.9",
+			want:  true,
+		},
+		{
+			name:  "under 14",
+			rule:  HeuristicPersonNonGrataRule{MaxAge: toPtr(13)},
+			bdate: tenYearsOld,
+			want:  true,
+		},
+		{
+			name:  "adult",
+			rule:  HeuristicPersonNonGrataRule{MinAge: toPtr(18)},
+			bdate: tenYearsOld,
+			want:  false,
+		},
+		{
+			name:  "born after 2010",
+			rule:  HeuristicPersonNonGrataRule{MinBirthYear: toPtr(2011)},
+			bdate: "5.6.2012",
+			want:  true,
+		},
+		{
+			name:  "age range does not match hidden year",
+			rule:  HeuristicPersonNonGrataRule{MaxAge: toPtr(13)},
+			bdate: "1.1",
+			want:  false,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			user := &object.UsersUser{Bdate: tt.bdate}
+			if got := tt.rule.Check(user); got != tt.want {
+				t.Errorf("Check() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHeuristicPersonNonGrataRuleMatch_HiddenYear(t *testing.T) {
+	t.Parallel()
+
+	rule := HeuristicPersonNonGrataRule{
+		Name:            toPtr("Bob Marley"),
+		BirthDate:       toPtr("6.2.1945"),
+		MatchHiddenYear: true,
+		FuzzyAction:     PunishmentDelete,
+	}
+
+	tests := []struct {
+		name  string
+		bdate string
+		want  Match
+	}{
+		{
+			name:  "full date",
+			bdate: "6.2.1945",
+			want:  exactMatch(BanReasonPersonNonGrata),
+		},
+		{
+			name:  "hidden year",
+			bdate: "6.2",
+			want:  Match{Reason: BanReasonPersonNonGrataPossible, Punishment: PunishmentDelete, Score: 1},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			user := &object.UsersUser{FirstName: "Bob", LastName: "Marley", Bdate: tt.bdate}
+			got, ok := rule.Match(user)
+			if !ok {
+				t.Fatalf("Match() ok = false, want true")
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Match() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHeuristicPersonNonGrataRuleCheck_Normalized(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		rule HeuristicPersonNonGrataRule
+		user object.UsersUser
+		want bool
+	}{
+		{
+			name: "name with homoglyphs",
+			rule: HeuristicPersonNonGrataRule{Name: toPtr("Сергей Иванов")},
+			user: object.UsersUser{FirstName: "Сeргей", LastName: "Ивaнов"},
+			want: true,
+		},
+		{
+			name: "different name",
+			rule: HeuristicPersonNonGrataRule{Name: toPtr("Сергей Иванов")},
+			user: object.UsersUser{FirstName: "Сергей", LastName: "Петров"},
+			want: false,
+		},
+		{
+			name: "obfuscated status",
+			rule: HeuristicPersonNonGrataRule{Status: toPtr("заработок")},
+			user: object.UsersUser{Status: "З а р а б о т о к без вложений"},
+			want: true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := tt.rule.Check(&tt.user); got != tt.want {
+				t.Errorf("Check() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHeuristicAttachmentRuleCheck(t *testing.T) {
+	t.Parallel()
+
+	sticker := Attachment{Type: AttachmentTypeSticker, Sticker: &StickerAttachment{ProductID: 1, StickerID: 2}}
+	apk := Attachment{Type: AttachmentTypeDoc, Doc: &DocAttachment{Title: "free.apk", Ext: "apk"}}
+	photo := Attachment{Type: AttachmentTypePhoto, Photo: &PhotoAttachment{ID: 1, OwnerID: 1}}
+	link := Attachment{Type: AttachmentTypeLink, Link: &LinkAttachment{URL: "https://Casino.example.com/win"}}
+
+	tests := []struct {
+		name    string
+		rule    HeuristicAttachmentRule
+		comment Comment
+		want    bool
+	}{
+		{
+			name:    "no attachments",
+			rule:    HeuristicAttachmentRule{BlankText: toPtr(true)},
+			comment: Comment{},
+			want:    false,
+		},
+		{
+			name:    "sticker only comment",
+			rule:    HeuristicAttachmentRule{Types: []string{"sticker"}, BlankText: toPtr(true)},
+			comment: Comment{Text: " ", Attachments: []Attachment{sticker}},
+			want:    true,
+		},
+		{
+			name:    "sticker with text",
+			rule:    HeuristicAttachmentRule{Types: []string{"sticker"}, BlankText: toPtr(true)},
+			comment: Comment{Text: "hello", Attachments: []Attachment{sticker}},
+			want:    false,
+		},
+		{
+			name:    "too many photos",
+			rule:    HeuristicAttachmentRule{Types: []string{"photo"}, MinCount: toPtr(3)},
+			comment: Comment{Attachments: []Attachment{photo, photo, sticker, photo}},
+			want:    true,
+		},
+		{
+			name:    "not enough photos",
+			rule:    HeuristicAttachmentRule{Types: []string{"photo"}, MinCount: toPtr(3)},
+			comment: Comment{Attachments: []Attachment{photo, photo, sticker}},
+			want:    false,
+		},
+		{
+			name:    "doc extension",
+			rule:    HeuristicAttachmentRule{DocExtensions: []string{"APK", "exe"}},
+			comment: Comment{Attachments: []Attachment{photo, apk}},
+			want:    true,
+		},
+		{
+			name:    "sticker id",
+			rule:    HeuristicAttachmentRule{StickerIDs: []int{2}},
+			comment: Comment{Attachments: []Attachment{sticker}},
+			want:    true,
+		},
+		{
+			name:    "link url",
+			rule:    HeuristicAttachmentRule{LinkURLs: []string{"casino"}},
+			comment: Comment{Attachments: []Attachment{link}},
+			want:    true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := tt.rule.Check(&tt.comment); got != tt.want {
+				t.Errorf("Check() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHeuristicRulesCheckComment_Profanity(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		rules HeuristicRules
+		text  string
+		want  bool
+	}{
+		{
+			name:  "profanity",
+			rules: HeuristicRules{Profanity: []HeuristicProfanityRule{{}}},
+			text:  "Ну ты и дебил",
+			want:  true,
+		},
+		{
+			name:  "clean text",
+			rules: HeuristicRules{Profanity: []HeuristicProfanityRule{{}}},
+			text:  "Спасибо за статью",
+			want:  false,
+		},
+		{
+			name:  "not enough profane words",
+			rules: HeuristicRules{Profanity: []HeuristicProfanityRule{{MinCount: toPtr(2)}}},
+			text:  "Ну ты и дебил",
+			want:  false,
+		},
+		{
+			name:  "obfuscated profanity",
+			rules: HeuristicRules{Profanity: []HeuristicProfanityRule{{Obfuscated: toPtr(true)}}},
+			text:  "Ах ты д е б и л",
+			want:  true,
+		},
+		{
+			name:  "plain profanity is not obfuscated",
+			rules: HeuristicRules{Profanity: []HeuristicProfanityRule{{Obfuscated: toPtr(true)}}},
+			text:  "Ну ты и дебил",
+			want:  false,
+		},
+		{
+			name:  "mixed script words",
+			rules: HeuristicRules{Profanity: []HeuristicProfanityRule{{MaxMixedScriptWords: toPtr(1)}}},
+			text:  "Зapaбoтoк бeз вложений",
+			want:  true,
+		},
+		{
+			name: "custom roots",
+			rules: HeuristicRules{
+				Profanity:      []HeuristicProfanityRule{{}},
+				ProfanityRoots: []string{"редиск*"},
+			},
+			text: "Ах ты редиска, а не дебил",
+			want: true,
+		},
+		{
+			name: "exceptions",
+			rules: HeuristicRules{
+				Profanity:           []HeuristicProfanityRule{{}},
+				ProfanityExceptions: []string{"дебил*"},
+			},
+			text: "Ну ты и дебил",
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			tt.rules.LoadProfanity()
+			match, got := tt.rules.CheckComment(&Comment{Text: tt.text}, nil)
+			if got != tt.want {
+				t.Errorf("CheckComment() = %v, want %v", got, tt.want)
+			}
+			if got && match.Reason != BanReasonProfanity {
+				t.Errorf("CheckComment() reason = %v, want %v", match.Reason, BanReasonProfanity)
+			}
+		})
+	}
+}
+
+func TestHeuristicRulesCheckComment_SpamPattern(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		rule         HeuristicSpamPatternRule
+		comment      Comment
+		want         bool
+		wantEntities []string
+	}{
+		{
+			name:         "phone",
+			rule:         HeuristicSpamPatternRule{Kinds: []string{"phone"}},
+			comment:      Comment{Text: "Звони 8 (999) 123-45-67"},
+			want:         true,
+			wantEntities: []string{"phone:+79991234567"},
+		},
+		{
+			name:    "other kind",
+			rule:    HeuristicSpamPatternRule{Kinds: []string{"wallet"}},
+			comment: Comment{Text: "Звони 8 (999) 123-45-67"},
+			want:    false,
+		},
+		{
+			name:    "not enough entities",
+			rule:    HeuristicSpamPatternRule{Kinds: []string{"phone"}, MinCount: toPtr(2)},
+			comment: Comment{Text: "Звони 8 (999) 123-45-67"},
+			want:    false,
+		},
+		{
+			name: "attached link",
+			rule: HeuristicSpamPatternRule{Kinds: []string{"telegram"}},
+			comment: Comment{Attachments: []Attachment{
+				{Type: AttachmentTypeLink, Link: &LinkAttachment{URL: "https://t.me/easymoney"}},
+			}},
+			want:         true,
+			wantEntities: []string{"telegram:@easymoney"},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			rules := HeuristicRules{SpamPattern: []HeuristicSpamPatternRule{tt.rule}}
+			match, got := rules.CheckComment(&tt.comment, nil)
+			if got != tt.want {
+				t.Errorf("CheckComment() = %v, want %v", got, tt.want)
+			}
+			if !reflect.DeepEqual(match.Entities, tt.wantEntities) {
+				t.Errorf("CheckComment() entities = %v, want %v", match.Entities, tt.wantEntities)
+			}
+		})
+	}
+}
+
+func TestHeuristicStyleRuleCheck(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		rule HeuristicStyleRule
+		text string
+		want bool
+	}{
+		{
+			name: "caps in long comment",
+			rule: HeuristicStyleRule{MinCapsRatio: toPtr(0.7), MinLength: toPtr(31)},
+			text: "СРОЧНО НУЖНЫ СОТРУДНИКИ НА УДАЛЕНКУ, ПИШИ",
+			want: true,
+		},
+		{
+			name: "caps in short comment",
+			rule: HeuristicStyleRule{MinCapsRatio: toPtr(0.7), MinLength: toPtr(31)},
+			text: "ДА, СОГЛАСЕН",
+			want: false,
+		},
+		{
+			name: "emoji",
+			rule: HeuristicStyleRule{MinEmojiRatio: toPtr(0.5)},
+			text: "🔥🔥🔥 жми 👉👉",
+			want: true,
+		},
+		{
+			name: "few emoji",
+			rule: HeuristicStyleRule{MinEmojiRatio: toPtr(0.5)},
+			text: "Отличная статья 👍",
+			want: false,
+		},
+		{
+			name: "repeated characters",
+			rule: HeuristicStyleRule{MinRepeatRun: toPtr(5)},
+			text: "Ураааааа!!!",
+			want: true,
+		},
+		{
+			name: "links",
+			rule: HeuristicStyleRule{MinLinkDensity: toPtr(0.5)},
+			text: "site.ru и https://example.com",
+			want: true,
+		},
+		{
+			name: "mentions",
+			rule: HeuristicStyleRule{MinMentionDensity: toPtr(0.5)},
+			text: "Смотрите @durov @id1 @club1",
+			want: true,
+		},
+		{
+			name: "no mentions",
+			rule: HeuristicStyleRule{MinMentionDensity: toPtr(0.5)},
+			text: "",
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			c := &Comment{Text: tt.text}
+			if got := tt.rule.Check(c.Style()); got != tt.want {
+				t.Errorf("Check() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHeuristicLanguageRuleCheck(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		rule HeuristicLanguageRule
+		text string
+		want bool
+	}{
+		{
+			name: "allowed language",
+			rule: HeuristicLanguageRule{Languages: []string{"ru", "uk"}},
+			text: "Подскажите, пожалуйста, где можно купить билеты?",
+			want: false,
+		},
+		{
+			name: "other language",
+			rule: HeuristicLanguageRule{Languages: []string{"ru", "uk"}},
+			text: "Hello guys, check my profile for hot photos",
+			want: true,
+		},
+		{
+			name: "short comment",
+			rule: HeuristicLanguageRule{Languages: []string{"ru", "uk"}},
+			text: "Hello guys",
+			want: false,
+		},
+		{
+			name: "short comment with min letters",
+			rule: HeuristicLanguageRule{Languages: []string{"ru", "uk"}, MinLetters: toPtr(5)},
+			text: "Hello guys",
+			want: true,
+		},
+		{
+			name: "unknown language",
+			rule: HeuristicLanguageRule{Languages: []string{"ru", "uk"}},
+			text: "ꦲꦤꦕꦫꦏ ꦲꦤꦕꦫꦏ ꦲꦤꦕꦫꦏ ꦲꦤꦕꦫꦏ ꦲꦤꦕꦫꦏ",
+			want: false,
+		},
+		{
+			name: "non-cyrillic letters",
+			rule: HeuristicLanguageRule{MaxNonCyrillic: toPtr(0.5)},
+			text: "Заработок online, best offer for you",
+			want: true,
+		},
+		{
+			name: "few non-cyrillic letters",
+			rule: HeuristicLanguageRule{MaxNonCyrillic: toPtr(0.5)},
+			text: "Купил новый iPhone, очень доволен покупкой",
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := tt.rule.Check(langdetect.Detect(tt.text)); got != tt.want {
+				t.Errorf("Check() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHeuristicRulesCheckComment_Classifier(t *testing.T) {
+	t.Parallel()
+
+	model := bayes.New()
+	model.Add(bayes.Tokens(textnorm.Normalize("Заработок от 5000 рублей в день без вложений, пиши в личку")), bayes.Spam)
+	model.Add(bayes.Tokens(textnorm.Normalize("Спасибо за статью, было очень интересно читать")), bayes.Ham)
+
+	tests := []struct {
+		name      string
+		rule      HeuristicClassifierRule
+		comment   Comment
+		want      bool
+		wantScore bool
+	}{
+		{
+			name:      "spam",
+			rule:      HeuristicClassifierRule{Threshold: 0.9, model: model},
+			comment:   Comment{Text: "Заработок без вложений, пишите в личку"},
+			want:      true,
+			wantScore: true,
+		},
+		{
+			name:    "ham",
+			rule:    HeuristicClassifierRule{Threshold: 0.9, model: model},
+			comment: Comment{Text: "Спасибо, очень интересно"},
+			want:    false,
+		},
+		{
+			name:    "not enough known tokens",
+			rule:    HeuristicClassifierRule{Threshold: 0.9, MinTokens: toPtr(10), model: model},
+			comment: Comment{Text: "Заработок без вложений, пишите в личку"},
+			want:    false,
+		},
+		{
+			name:    "model not loaded",
+			rule:    HeuristicClassifierRule{Threshold: 0.9},
+			comment: Comment{Text: "Заработок без вложений, пишите в личку"},
+			want:    false,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			rules := HeuristicRules{Classifier: []HeuristicClassifierRule{tt.rule}}
+			match, got := rules.CheckComment(&tt.comment, nil)
+			if got != tt.want {
+				t.Errorf("CheckComment() = %v, want %v", got, tt.want)
+			}
+			if tt.wantScore && match.Score < tt.rule.Threshold {
+				t.Errorf("CheckComment() score = %v, want at least %v", match.Score, tt.rule.Threshold)
+			}
+		})
+	}
+}
+
+func toPtr[T any](v T) *T {
+	return &v
+}
@@ -0,0 +1,80 @@
+package entity
+
+import (
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// linkRe matches links with scheme or www prefix, and bare links like "example.com/path".
+var linkRe = regexp.MustCompile(
+	`(?i)(?:https?://|www\.)[^\s<>"'\]]+` +
+		`|(?:[a-z0-9а-яё](?:[a-z0-9а-яё-]*[a-z0-9а-яё])?\.)+[a-zа-яё]{2,}(?:/[^\s<>"'\]]*)?`,
+)
+
+// bareLinkDomains are top-level domains of links written without scheme.
+// Other bare links are ignored to avoid matching abbreviations like "т.е." or "Mr.Smith".
+var bareLinkDomains = map[string]struct{}{
+	"com": {}, "ru": {}, "рф": {}, "su": {}, "net": {}, "org": {}, "info": {}, "biz": {},
+	"io": {}, "me": {}, "cc": {}, "ly": {}, "gl": {}, "link": {}, "click": {}, "top": {},
+	"xyz": {}, "online": {}, "site": {}, "club": {}, "pro": {}, "shop": {}, "ua": {}, "by": {}, "kz": {},
+}
+
+// ParseLinks returns links from text.
+func ParseLinks(text string) []string {
+	var links []string
+	for _, loc := range linkRe.FindAllStringIndex(text, -1) {
+		// Domains of emails are not links.
+		if loc[0] > 0 && text[loc[0]-1] == '@' {
+			continue
+		}
+		link := strings.TrimRight(text[loc[0]:loc[1]], ".,!?:;)")
+
+		lower := strings.ToLower(link)
+		if !strings.HasPrefix(lower, "http://") && !strings.HasPrefix(lower, "https://") &&
+			!strings.HasPrefix(lower, "www.") {
+			host := LinkHost(link)
+			if _, ok := bareLinkDomains[host[strings.LastIndexByte(host, '.')+1:]]; !ok {
+				continue
+			}
+		}
+
+		links = append(links, link)
+	}
+
+	return links
+}
+
+// LinkHost returns lowercase host of the link without www prefix.
+// It returns empty string if link is malformed.
+func LinkHost(link string) string {
+	if !strings.Contains(link, "://") {
+		link = "http://" + link
+	}
+
+	u, err := url.Parse(link)
+	if err != nil {
+		return ""
+	}
+
+	return strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+}
+
+// MatchDomain reports whether host matches domain pattern.
+// Pattern "example.com" matches the domain only, "*.example.com" matches its subdomains.
+func MatchDomain(pattern, host string) bool {
+	matched, err := path.Match(strings.ToLower(pattern), host)
+	return err == nil && matched
+}
+
+// matchAnyDomain reports whether host matches any of domain patterns.
+func matchAnyDomain(patterns []string, host string) bool {
+	for _, p := range patterns {
+		if MatchDomain(p, host) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,146 @@
+package entity
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseLinks(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{
+			name: "no links",
+			text: "т.е. Mr.Smith, write to me at bob@example.com",
+			want: nil,
+		},
+		{
+			name: "links with scheme",
+			text: "look https://vk.cc/abc! and http://пример.рф/путь.",
+			want: []string{"https://vk.cc/abc", "http://пример.рф/путь"},
+		},
+		{
+			name: "bare links",
+			text: "заходи на сайт.рф, www.Example.COM/x или vk.com/id1",
+			want: []string{"сайт.рф", "www.Example.COM/x", "vk.com/id1"},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := ParseLinks(tt.text); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseLinks() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLinkHost(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		link string
+		want string
+	}{
+		{link: "https://vk.cc/abc", want: "vk.cc"},
+		{link: "www.Example.COM/x", want: "example.com"},
+		{link: "http://пример.рф/путь", want: "пример.рф"},
+		{link: "сайт.рф", want: "сайт.рф"},
+	}
+	for _, tt := range tests {
+		if got := LinkHost(tt.link); got != tt.want {
+			t.Errorf("LinkHost(%q) = %v, want %v", tt.link, got, tt.want)
+		}
+	}
+}
+
+func TestHeuristicLinkRule_Check(t *testing.T) {
+	t.Parallel()
+
+	young := func() (time.Duration, bool) { return time.Hour, true }
+	unknown := func() (time.Duration, bool) { return 0, false }
+
+	tests := []struct {
+		name       string
+		rule       HeuristicLinkRule
+		hosts      []string
+		accountAge func() (time.Duration, bool)
+		want       bool
+	}{
+		{
+			name:       "denied domain",
+			rule:       HeuristicLinkRule{DenyDomains: []string{"scam.ru"}},
+			hosts:      []string{"vk.com", "scam.ru"},
+			accountAge: unknown,
+			want:       true,
+		},
+		{
+			name:       "denied subdomain",
+			rule:       HeuristicLinkRule{DenyDomains: []string{"*.scam.ru"}},
+			hosts:      []string{"promo.scam.ru"},
+			accountAge: unknown,
+			want:       true,
+		},
+		{
+			name:       "wildcard does not match the domain itself",
+			rule:       HeuristicLinkRule{DenyDomains: []string{"*.scam.ru"}},
+			hosts:      []string{"scam.ru"},
+			accountAge: unknown,
+			want:       false,
+		},
+		{
+			name:       "any link except allowed",
+			rule:       HeuristicLinkRule{AllowDomains: []string{"vk.com", "*.vk.com"}},
+			hosts:      []string{"m.vk.com", "vk.com"},
+			accountAge: unknown,
+			want:       false,
+		},
+		{
+			name:       "link from young account",
+			rule:       HeuristicLinkRule{MaxAccountAge: 24 * time.Hour},
+			hosts:      []string{"example.com"},
+			accountAge: young,
+			want:       true,
+		},
+		{
+			name:       "link from account of unknown age",
+			rule:       HeuristicLinkRule{MaxAccountAge: 24 * time.Hour},
+			hosts:      []string{"example.com"},
+			accountAge: unknown,
+			want:       false,
+		},
+		{
+			name:       "young account without links",
+			rule:       HeuristicLinkRule{MaxAccountAge: 24 * time.Hour},
+			hosts:      nil,
+			accountAge: young,
+			want:       false,
+		},
+		{
+			name:       "not denied link from young account",
+			rule:       HeuristicLinkRule{DenyDomains: []string{"scam.ru"}, MaxAccountAge: 24 * time.Hour},
+			hosts:      []string{"example.com"},
+			accountAge: young,
+			want:       false,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := tt.rule.Check(tt.hosts, tt.accountAge); got != tt.want {
+				t.Errorf("Check() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package entity
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// MentionType describes type of mentioned object.
+type MentionType string
+
+// Available mention types.
+const (
+	MentionTypeUser      MentionType = "user"
+	MentionTypeCommunity MentionType = "community"
+	// MentionTypeScreenName is a mention by screen name, it may be both user and community.
+	MentionTypeScreenName MentionType = "screen_name"
+)
+
+// Mention describes mention in comment text.
+type Mention struct {
+	Type MentionType
+	// ID is a positive ID of user or community, it is 0 for mentions by screen name.
+	ID int
+	// ScreenName is a mention target as written in text, like "id1", "club1" or "durov".
+	ScreenName string
+	// Name is a displayed name, it may be empty.
+	Name string
+}
+
+var (
+	// markupMentionRegexp matches mentions like "[id1|Pavel]" and "[club1|VK]".
+	markupMentionRegexp = regexp.MustCompile(`\[([A-Za-z0-9_.]+)\|([^\]]*)\]`)
+	// atMentionRegexp matches mentions like "@durov" and "@id1 (Pavel)".
+	atMentionRegexp = regexp.MustCompile(`(?:^|[^\w@*])[@*]([A-Za-z0-9_.]*[A-Za-z0-9_])(?: \(([^)]*)\))?`)
+	// numericTargetRegexp matches numeric mention targets like "id1" and "club1".
+	numericTargetRegexp = regexp.MustCompile(`^(id|club|public|event)(\d+)$`)
+)
+
+// ParseMentions extracts mentions from VK text markup.
+func ParseMentions(text string) []Mention {
+	var mentions []Mention
+
+	for _, m := range markupMentionRegexp.FindAllStringSubmatch(text, -1) {
+		mentions = append(mentions, newMention(m[1], m[2]))
+	}
+
+	// Remove markup mentions, so their names are not parsed again.
+	text = markupMentionRegexp.ReplaceAllString(text, " ")
+	for _, m := range atMentionRegexp.FindAllStringSubmatch(text, -1) {
+		mentions = append(mentions, newMention(m[1], m[2]))
+	}
+
+	return mentions
+}
+
+func newMention(target, name string) Mention {
+	mention := Mention{
+		Type:       MentionTypeScreenName,
+		ScreenName: strings.ToLower(target),
+		Name:       name,
+	}
+
+	m := numericTargetRegexp.FindStringSubmatch(mention.ScreenName)
+	if m == nil {
+		return mention
+	}
+
+	id, err := strconv.Atoi(m[2])
+	if err != nil || id == 0 {
+		return mention
+	}
+
+	mention.ID = id
+	if m[1] == "id" {
+		mention.Type = MentionTypeUser
+	} else {
+		mention.Type = MentionTypeCommunity
+	}
+
+	return mention
+}
@@ -0,0 +1,58 @@
+package entity
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseMentions(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		text string
+		want []Mention
+	}{
+		{
+			name: "no mentions",
+			text: "hello, write to me at bob@example.com",
+			want: nil,
+		},
+		{
+			name: "markup mentions",
+			text: "[id1|Pavel], [club22|VK] and [public3|Public]",
+			want: []Mention{
+				{Type: MentionTypeUser, ID: 1, ScreenName: "id1", Name: "Pavel"},
+				{Type: MentionTypeCommunity, ID: 22, ScreenName: "club22", Name: "VK"},
+				{Type: MentionTypeCommunity, ID: 3, ScreenName: "public3", Name: "Public"},
+			},
+		},
+		{
+			name: "markup mention by screen name",
+			text: "[Durov|Pavel Durov]",
+			want: []Mention{
+				{Type: MentionTypeScreenName, ScreenName: "durov", Name: "Pavel Durov"},
+			},
+		},
+		{
+			name: "at mentions",
+			text: "@durov, look at @id1 (Pavel) and *club2.",
+			want: []Mention{
+				{Type: MentionTypeScreenName, ScreenName: "durov"},
+				{Type: MentionTypeUser, ID: 1, ScreenName: "id1", Name: "Pavel"},
+				{Type: MentionTypeCommunity, ID: 2, ScreenName: "club2"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := ParseMentions(tt.text); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseMentions() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
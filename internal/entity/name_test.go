@@ -0,0 +1,47 @@
+package entity
+
+import "testing"
+
+func TestNormalizeName(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{name: "cyrillic", in: "Сергей Иванов", want: "sergey ivanov"},
+		{name: "latin", in: "Sergey Ivanov", want: "sergey ivanov"},
+		{name: "latin homoglyphs in cyrillic", in: "Сeргей Ивaнов", want: "sergey ivanov"},
+		{name: "latin first letter in cyrillic", in: "Cергей Ивaнов", want: "sergey ivanov"},
+		{name: "digits", in: "Serge1 Ivan0v", want: "sergei ivanov"},
+		{name: "punctuation", in: " Sergey_Ivanov!! ", want: "sergey ivanov"},
+		{name: "fullwidth and zero-width", in: "Ｓｅｒｇｅｙ Ива\u200bнов", want: "sergey ivanov"},
+		{name: "spaced-out letters", in: "С е р г е й Иванов", want: "sergey ivanov"},
+	}
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := NormalizeName(tt.in); got != tt.want {
+				t.Errorf("NormalizeName() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNameSimilarity(t *testing.T) {
+	t.Parallel()
+
+	if got := NameSimilarity("Сергей Иванов", "Сeргей Ивaнов"); got != 1 {
+		t.Errorf("NameSimilarity() of homoglyphs = %v, want 1", got)
+	}
+	if got := NameSimilarity("Сергей Иванов", "Serge1 Ivanov"); got < 0.9 {
+		t.Errorf("NameSimilarity() of misspelled name = %v, want >= 0.9", got)
+	}
+	if got := NameSimilarity("Сергей Иванов", "Bob Marley"); got > 0.6 {
+		t.Errorf("NameSimilarity() of different names = %v, want <= 0.6", got)
+	}
+}
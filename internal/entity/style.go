@@ -0,0 +1,114 @@
+package entity
+
+import (
+	"strings"
+	"unicode"
+)
+
+// TextStyle contains stylistic features of comment text.
+type TextStyle struct {
+	// Length is a number of characters without spaces.
+	Length int
+	// Words is a number of words.
+	Words int
+	// Letters is a number of letters with case, uppercase ones are counted in Uppercase.
+	Letters   int
+	Uppercase int
+	// Emoji is a number of emoji, modifiers and joiners are not counted anywhere.
+	Emoji int
+	// MaxRepeat is a length of the longest run of the same character, case is ignored.
+	MaxRepeat int
+	Links     int
+	Mentions  int
+}
+
+// Style returns stylistic features of comment text.
+func (c *Comment) Style() TextStyle {
+	s := TextStyle{
+		Words:    len(strings.Fields(c.Text)),
+		Links:    len(ParseLinks(c.Text)),
+		Mentions: len(c.Mentions()),
+	}
+
+	var (
+		prev rune
+		run  int
+	)
+	for _, r := range c.Text {
+		if unicode.IsSpace(r) {
+			prev, run = 0, 0
+			continue
+		}
+		if isEmojiModifier(r) {
+			continue
+		}
+		s.Length++
+
+		switch {
+		case unicode.IsUpper(r):
+			s.Letters++
+			s.Uppercase++
+		case unicode.IsLower(r):
+			s.Letters++
+		case isEmoji(r):
+			s.Emoji++
+		}
+
+		r = unicode.ToLower(r)
+		if r == prev {
+			run++
+		} else {
+			prev, run = r, 1
+		}
+		s.MaxRepeat = maxInt(s.MaxRepeat, run)
+	}
+
+	return s
+}
+
+// CapsRatio returns share of uppercase letters, from 0 to 1.
+func (s TextStyle) CapsRatio() float64 {
+	return ratio(s.Uppercase, s.Letters)
+}
+
+// EmojiRatio returns share of emoji among characters, from 0 to 1.
+func (s TextStyle) EmojiRatio() float64 {
+	return ratio(s.Emoji, s.Length)
+}
+
+// LinkDensity returns number of links per word.
+func (s TextStyle) LinkDensity() float64 {
+	return ratio(s.Links, s.Words)
+}
+
+// MentionDensity returns number of mentions per word.
+func (s TextStyle) MentionDensity() float64 {
+	return ratio(s.Mentions, s.Words)
+}
+
+func ratio(n, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+
+	return float64(n) / float64(total)
+}
+
+// isEmoji reports whether r is a pictographic emoji or a regional indicator of a flag.
+func isEmoji(r rune) bool {
+	switch {
+	case r >= 0x1F000 && r <= 0x1FAFF: // Pictographs, emoticons, transport, flags and supplemental symbols.
+		return true
+	case r >= 0x2600 && r <= 0x27BF: // Miscellaneous symbols and dingbats.
+		return true
+	case r >= 0x2B00 && r <= 0x2BFF: // Arrows, stars and circles.
+		return true
+	default:
+		return false
+	}
+}
+
+// isEmojiModifier reports whether r is a skin tone modifier, variation selector or zero width joiner.
+func isEmojiModifier(r rune) bool {
+	return (r >= 0x1F3FB && r <= 0x1F3FF) || r == 0xFE0F || r == 0x200D
+}
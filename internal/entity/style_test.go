@@ -0,0 +1,50 @@
+package entity
+
+import (
+	"testing"
+)
+
+func TestCommentStyle(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		text string
+		want TextStyle
+	}{
+		{
+			name: "caps and repeats",
+			text: "СРОЧНО!!!!! Заработок",
+			want: TextStyle{Length: 20, Words: 2, Letters: 15, Uppercase: 7, MaxRepeat: 5},
+		},
+		{
+			name: "emoji",
+			text: "Привет 👋🏻 🔥🔥 ❤️",
+			want: TextStyle{Length: 10, Words: 4, Letters: 6, Uppercase: 1, Emoji: 4, MaxRepeat: 2},
+		},
+		{
+			name: "links and mentions",
+			text: "Пиши [id1|Павлу] или @durov, все на site.ru",
+			want: TextStyle{
+				Length: 37, Words: 7, Letters: 30, Uppercase: 2, MaxRepeat: 1, Links: 1, Mentions: 2,
+			},
+		},
+		{
+			name: "empty",
+			text: "",
+			want: TextStyle{},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			c := &Comment{Text: tt.text}
+			if got := c.Style(); got != tt.want {
+				t.Errorf("Style() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
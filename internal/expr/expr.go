@@ -0,0 +1,136 @@
+package expr
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Type is a type of expression value.
+type Type int
+
+// Available types, values of them are bool, int, float64, string, []string and []int.
+const (
+	TypeBool Type = iota + 1
+	TypeInt
+	TypeFloat
+	TypeString
+	TypeStrings
+	TypeInts
+)
+
+func (t Type) String() string {
+	switch t {
+	case TypeBool:
+		return "bool"
+	case TypeInt:
+		return "int"
+	case TypeFloat:
+		return "float"
+	case TypeString:
+		return "string"
+	case TypeStrings:
+		return "list of strings"
+	case TypeInts:
+		return "list of ints"
+	default:
+		return "unknown"
+	}
+}
+
+func (t Type) isNumber() bool {
+	return t == TypeInt || t == TypeFloat
+}
+
+// Func is a function available in expressions.
+type Func struct {
+	Args   []Type
+	Result Type
+	Fn     func(args []any) any
+}
+
+// Config declares variables and functions available in expressions.
+type Config struct {
+	Vars  map[string]Type
+	Funcs map[string]Func
+}
+
+// Env returns values of variables.
+// Value must have the declared type, it is requested only for variables used by the expression.
+// Missing value is nil, comparisons, checks and functions of it are missing too.
+// Logic is three-valued: not of missing is missing, "false and missing" is false,
+// "true or missing" is true, the expression is false if it is missing at the top level.
+type Env interface {
+	Get(name string) any
+}
+
+// Error is a compilation error.
+type Error struct {
+	// Pos is a 1-based position of the error in runes.
+	Pos int
+	Msg string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("at %d: %s", e.Pos, e.Msg)
+}
+
+func errorf(pos int, format string, args ...any) error {
+	return &Error{Pos: pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+// Program is a compiled boolean expression.
+type Program struct {
+	source string
+	vars   []string
+	eval   func(Env) any
+}
+
+// Compile parses and type-checks boolean expression.
+func Compile(source string, cfg Config) (*Program, error) {
+	tokens, err := lex(source)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens, cfg: cfg, vars: make(map[string]struct{})}
+	n, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokenEOF {
+		return nil, errorf(t.pos, "unexpected %q", t.text)
+	}
+	if n.typ != TypeBool {
+		return nil, errorf(1, "expression is %s, not bool", n.typ)
+	}
+
+	vars := make([]string, 0, len(p.vars))
+	for v := range p.vars {
+		vars = append(vars, v)
+	}
+	sort.Strings(vars)
+
+	return &Program{source: source, vars: vars, eval: n.eval}, nil
+}
+
+// Vars returns names of variables used by the expression.
+func (p *Program) Vars() []string {
+	return p.vars
+}
+
+// String returns source of the expression.
+func (p *Program) String() string {
+	return p.source
+}
+
+// Eval evaluates the expression, and and or are short-circuit.
+// Missing result is false.
+func (p *Program) Eval(env Env) bool {
+	return isTrue(p.eval(env))
+}
+
+// isTrue reports whether bool value is true, missing value is false.
+func isTrue(v any) bool {
+	b, _ := v.(bool)
+	return b
+}
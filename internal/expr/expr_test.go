@@ -0,0 +1,194 @@
+package expr
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type mapEnv map[string]any
+
+func (e mapEnv) Get(name string) any {
+	return e[name]
+}
+
+var testConfig = Config{
+	Vars: map[string]Type{
+		"user.name":     TypeString,
+		"user.age":      TypeInt,
+		"user.verified": TypeBool,
+		"comment.links": TypeStrings,
+		"comment.caps":  TypeFloat,
+		"post.id":       TypeInt,
+	},
+	Funcs: map[string]Func{
+		"upper": {
+			Args:   []Type{TypeString},
+			Result: TypeString,
+			Fn:     func(args []any) any { return strings.ToUpper(args[0].(string)) },
+		},
+	},
+}
+
+var testEnv = mapEnv{
+	"user.name":     "Иван Иванов",
+	"user.age":      17,
+	"user.verified": false,
+	"comment.links": []string{"vk.com", "Scam.ru"},
+	"comment.caps":  0.8,
+	"post.id":       42,
+}
+
+func TestProgramEval(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		expr string
+		want bool
+	}{
+		{name: "string equality", expr: `user.name == "Иван Иванов"`, want: true},
+		{name: "string inequality", expr: `user.name != "Иван Иванов"`, want: false},
+		{name: "int comparison", expr: `user.age < 18`, want: true},
+		{name: "int and float comparison", expr: `user.age >= 17.5`, want: false},
+		{name: "float comparison", expr: `comment.caps > 0.7`, want: true},
+		{name: "negative number", expr: `user.age > -1`, want: true},
+		{name: "bool variable", expr: `not user.verified`, want: true},
+		{name: "bool literal", expr: `user.verified == false`, want: true},
+		{name: "list contains", expr: `comment.links contains "scam.ru"`, want: true},
+		{name: "string contains", expr: `user.name contains "иванов"`, want: true},
+		{name: "in string list", expr: `user.name in ["Петр Петров", "Иван Иванов"]`, want: true},
+		{name: "in int list", expr: `post.id in [1, 2, 3]`, want: false},
+		{name: "matches", expr: `user.name matches "^Иван\\s"`, want: true},
+		{name: "len", expr: `len(comment.links) == 2 and len(user.name) == 11`, want: true},
+		{name: "function", expr: `upper(user.name) == "ИВАН ИВАНОВ"`, want: true},
+		{
+			name: "precedence",
+			expr: `user.name == "Петр" or user.age < 18 and not user.verified`,
+			want: true,
+		},
+		{
+			name: "parentheses",
+			expr: `(user.name == "Петр" or user.age < 18) and len(comment.links) > 5`,
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			p, err := Compile(tt.expr, testConfig)
+			if err != nil {
+				t.Fatalf("Compile() error = %v", err)
+			}
+			if got := p.Eval(testEnv); got != tt.want {
+				t.Errorf("Eval() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProgramEval_Missing(t *testing.T) {
+	t.Parallel()
+
+	env := mapEnv{"user.name": "Иван Иванов"}
+	tests := []struct {
+		name string
+		expr string
+		want bool
+	}{
+		{name: "int comparison", expr: `user.age < 18`, want: false},
+		{name: "int inequality", expr: `user.age != 18`, want: false},
+		{name: "string equality", expr: `post.id == 1 or user.name == "Иван Иванов"`, want: true},
+		{name: "bool variable", expr: `user.verified`, want: false},
+		{name: "contains", expr: `comment.links contains "scam.ru"`, want: false},
+		{name: "len", expr: `len(comment.links) == 0`, want: false},
+		{name: "function", expr: `upper(user.name) == upper(user.name)`, want: true},
+		{name: "function of missing", expr: `upper(comment.text) == ""`, want: false},
+		{name: "not comparison", expr: `not (user.age >= 18)`, want: false},
+		{name: "not bool variable", expr: `not user.verified`, want: false},
+		{name: "double not", expr: `not not (user.age < 18)`, want: false},
+		{name: "not and", expr: `not (user.age < 18 and user.name == "Иван Иванов")`, want: false},
+		{name: "false and missing", expr: `not (user.age < 18 and user.name == "Петр")`, want: true},
+		{name: "true or missing", expr: `user.name == "Иван Иванов" or not user.verified`, want: true},
+		{name: "false or missing", expr: `not (user.name == "Петр" or user.age < 18)`, want: false},
+		{name: "not contains", expr: `not (comment.links contains "scam.ru")`, want: false},
+		{name: "not matches", expr: `not (comment.text matches "^a")`, want: false},
+	}
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			cfg := Config{Vars: map[string]Type{"comment.text": TypeString}, Funcs: testConfig.Funcs}
+			for name, typ := range testConfig.Vars {
+				cfg.Vars[name] = typ
+			}
+			p, err := Compile(tt.expr, cfg)
+			if err != nil {
+				t.Fatalf("Compile() error = %v", err)
+			}
+			if got := p.Eval(env); got != tt.want {
+				t.Errorf("Eval() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompile_Errors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		expr    string
+		wantPos int
+	}{
+		{name: "unknown variable", expr: `user.nam == "Иван"`, wantPos: 1},
+		{name: "not bool", expr: `user.age`, wantPos: 1},
+		{name: "type mismatch", expr: `user.age == "17"`, wantPos: 10},
+		{name: "ordered strings", expr: `user.name < "Иван"`, wantPos: 11},
+		{name: "and of int", expr: `user.verified and post.id`, wantPos: 19},
+		{name: "invalid regexp", expr: `user.name matches "("`, wantPos: 19},
+		{name: "regexp variable", expr: `user.name matches user.name`, wantPos: 19},
+		{name: "mixed list", expr: `post.id in [1, "2"]`, wantPos: 16},
+		{name: "unknown function", expr: `lower(user.name) == "иван"`, wantPos: 1},
+		{name: "wrong argument", expr: `upper(post.id) == "1"`, wantPos: 7},
+		{name: "unterminated string", expr: `user.name == "Иван`, wantPos: 14},
+		{name: "unexpected character", expr: `user.age > 1 & true`, wantPos: 14},
+		{name: "missing parenthesis", expr: `(user.verified`, wantPos: 15},
+		{name: "trailing tokens", expr: `user.verified user.verified`, wantPos: 15},
+		{name: "empty", expr: ``, wantPos: 1},
+	}
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := Compile(tt.expr, testConfig)
+			var exprErr *Error
+			if !errors.As(err, &exprErr) {
+				t.Fatalf("Compile() error = %v, want *Error", err)
+			}
+			if exprErr.Pos != tt.wantPos {
+				t.Errorf("Compile() error = %v, want position %d", err, tt.wantPos)
+			}
+		})
+	}
+}
+
+func TestProgramVars(t *testing.T) {
+	t.Parallel()
+
+	p, err := Compile(`user.age < 18 and (post.id == 1 or user.age > 60)`, testConfig)
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+	if want := []string{"post.id", "user.age"}; !reflect.DeepEqual(p.Vars(), want) {
+		t.Errorf("Vars() = %v, want %v", p.Vars(), want)
+	}
+}
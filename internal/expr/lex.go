@@ -0,0 +1,114 @@
+package expr
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenNumber
+	tokenString
+	tokenPunct
+)
+
+type token struct {
+	kind tokenKind
+	// text is an identifier, a number, an unquoted string or a punctuation.
+	text string
+	// pos is a 1-based position of the token in runes.
+	pos int
+}
+
+// keywords are identifiers used as operators and literals.
+var keywords = map[string]bool{
+	"and": true, "or": true, "not": true, "in": true, "contains": true, "matches": true,
+	"true": true, "false": true,
+}
+
+// punctuation contains operators and delimiters, longer ones go first.
+var punctuation = []string{"==", "!=", "<=", ">=", "<", ">", "(", ")", "[", "]", ",", "-"}
+
+func lex(src string) ([]token, error) {
+	var tokens []token
+
+	pos := 1
+	for i := 0; i < len(src); {
+		r, size := utf8.DecodeRuneInString(src[i:])
+		start := pos
+
+		switch {
+		case unicode.IsSpace(r):
+			i += size
+			pos++
+			continue
+		case r == '"':
+			end := closingQuote(src, i)
+			if end < 0 {
+				return nil, errorf(start, "unterminated string")
+			}
+			s, err := strconv.Unquote(src[i : end+1])
+			if err != nil {
+				return nil, errorf(start, "invalid string %s", src[i:end+1])
+			}
+			tokens = append(tokens, token{kind: tokenString, text: s, pos: start})
+			pos += utf8.RuneCountInString(src[i : end+1])
+			i = end + 1
+			continue
+		case unicode.IsDigit(r):
+			end := i + strings.IndexFunc(src[i:]+" ", func(r rune) bool { return !unicode.IsDigit(r) && r != '.' })
+			tokens = append(tokens, token{kind: tokenNumber, text: src[i:end], pos: start})
+			pos += end - i
+			i = end
+			continue
+		case unicode.IsLetter(r) || r == '_':
+			end := i + strings.IndexFunc(src[i:]+" ", func(r rune) bool {
+				return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' && r != '.'
+			})
+			word := src[i:end]
+			kind := tokenIdent
+			if keywords[word] {
+				kind = tokenPunct
+			}
+			tokens = append(tokens, token{kind: kind, text: word, pos: start})
+			pos += utf8.RuneCountInString(word)
+			i = end
+			continue
+		}
+
+		matched := false
+		for _, p := range punctuation {
+			if strings.HasPrefix(src[i:], p) {
+				tokens = append(tokens, token{kind: tokenPunct, text: p, pos: start})
+				pos += len(p)
+				i += len(p)
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return nil, errorf(start, "unexpected %q", r)
+		}
+	}
+
+	return append(tokens, token{kind: tokenEOF, pos: pos}), nil
+}
+
+// closingQuote returns index of the quote closing the string starting at i, or -1.
+func closingQuote(src string, i int) int {
+	for j := i + 1; j < len(src); j++ {
+		switch src[j] {
+		case '\\':
+			j++
+		case '"':
+			return j
+		}
+	}
+
+	return -1
+}
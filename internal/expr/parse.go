@@ -0,0 +1,549 @@
+package expr
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// node is a type-checked expression node compiled into a function.
+type node struct {
+	typ  Type
+	eval func(Env) any
+	pos  int
+}
+
+// parser compiles expressions with precedence from the lowest:
+// or, and, not, comparisons, operands.
+type parser struct {
+	tokens []token
+	i      int
+	cfg    Config
+	// vars contains names of used variables.
+	vars map[string]struct{}
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.i]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.i]
+	if t.kind != tokenEOF {
+		p.i++
+	}
+
+	return t
+}
+
+func (p *parser) accept(punct string) bool {
+	if t := p.peek(); t.kind == tokenPunct && t.text == punct {
+		p.i++
+		return true
+	}
+
+	return false
+}
+
+func (p *parser) expect(punct string) error {
+	if t := p.peek(); !p.accept(punct) {
+		return errorf(t.pos, "expected %q", punct)
+	}
+
+	return nil
+}
+
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return node{}, err
+	}
+	for p.peek().text == "or" && p.peek().kind == tokenPunct {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return node{}, err
+		}
+		if err := checkBool(left, right); err != nil {
+			return node{}, err
+		}
+		l, r := left.eval, right.eval
+		left = node{typ: TypeBool, pos: left.pos, eval: func(env Env) any {
+			return or(l, r, env)
+		}}
+	}
+
+	return left, nil
+}
+
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return node{}, err
+	}
+	for p.peek().text == "and" && p.peek().kind == tokenPunct {
+		p.next()
+		right, err := p.parseNot()
+		if err != nil {
+			return node{}, err
+		}
+		if err := checkBool(left, right); err != nil {
+			return node{}, err
+		}
+		l, r := left.eval, right.eval
+		left = node{typ: TypeBool, pos: left.pos, eval: func(env Env) any {
+			return and(l, r, env)
+		}}
+	}
+
+	return left, nil
+}
+
+func (p *parser) parseNot() (node, error) {
+	if t := p.peek(); p.accept("not") {
+		operand, err := p.parseNot()
+		if err != nil {
+			return node{}, err
+		}
+		if err := checkBool(operand); err != nil {
+			return node{}, err
+		}
+		eval := operand.eval
+		return node{typ: TypeBool, pos: t.pos, eval: func(env Env) any {
+			if v := eval(env); v != nil {
+				return !v.(bool)
+			}
+			return nil
+		}}, nil
+	}
+
+	return p.parseComparison()
+}
+
+// and is false if any operand is false, otherwise it is missing if any operand is missing.
+func and(l, r func(Env) any, env Env) any {
+	a := l(env)
+	if a == false {
+		return false
+	}
+	b := r(env)
+	if b == false {
+		return false
+	}
+	if a == nil || b == nil {
+		return nil
+	}
+	return true
+}
+
+// or is true if any operand is true, otherwise it is missing if any operand is missing.
+func or(l, r func(Env) any, env Env) any {
+	a := l(env)
+	if a == true {
+		return true
+	}
+	b := r(env)
+	if b == true {
+		return true
+	}
+	if a == nil || b == nil {
+		return nil
+	}
+	return false
+}
+
+func checkBool(nodes ...node) error {
+	for _, n := range nodes {
+		if n.typ != TypeBool {
+			return errorf(n.pos, "expected bool, got %s", n.typ)
+		}
+	}
+
+	return nil
+}
+
+func (p *parser) parseComparison() (node, error) {
+	left, err := p.parseOperand()
+	if err != nil {
+		return node{}, err
+	}
+
+	op := p.peek()
+	if op.kind != tokenPunct {
+		return left, nil
+	}
+	switch op.text {
+	case "==", "!=", "<", "<=", ">", ">=", "in", "contains", "matches":
+		p.next()
+	default:
+		return left, nil
+	}
+
+	if op.text == "matches" {
+		pattern := p.next()
+		if pattern.kind != tokenString {
+			return node{}, errorf(pattern.pos, "matches expects a string literal")
+		}
+		return compileMatches(left, pattern)
+	}
+
+	right, err := p.parseOperand()
+	if err != nil {
+		return node{}, err
+	}
+
+	switch op.text {
+	case "in":
+		return compileContains(right, left, op.pos)
+	case "contains":
+		return compileContains(left, right, op.pos)
+	default:
+		return compileCompare(op, left, right)
+	}
+}
+
+func compileMatches(left node, pattern token) (node, error) {
+	if left.typ != TypeString {
+		return node{}, errorf(left.pos, "matches expects string, got %s", left.typ)
+	}
+	re, err := regexp.Compile(pattern.text)
+	if err != nil {
+		return node{}, errorf(pattern.pos, "invalid regexp: %v", err)
+	}
+
+	eval := left.eval
+	return node{typ: TypeBool, pos: left.pos, eval: func(env Env) any {
+		s, ok := eval(env).(string)
+		if !ok {
+			return nil
+		}
+		return re.MatchString(s)
+	}}, nil
+}
+
+// compileContains compiles check of substring or list element, strings are compared case-insensitively.
+// The check is missing if any of the values is missing.
+func compileContains(container, element node, pos int) (node, error) {
+	c, e := container.eval, element.eval
+
+	switch {
+	case container.typ == TypeString && element.typ == TypeString:
+		return node{typ: TypeBool, pos: pos, eval: func(env Env) any {
+			s, ok := c(env).(string)
+			sub, subOK := e(env).(string)
+			if !ok || !subOK {
+				return nil
+			}
+			return strings.Contains(strings.ToLower(s), strings.ToLower(sub))
+		}}, nil
+	case container.typ == TypeStrings && element.typ == TypeString:
+		return node{typ: TypeBool, pos: pos, eval: func(env Env) any {
+			v, ok := e(env).(string)
+			list, listOK := c(env).([]string)
+			if !ok || !listOK {
+				return nil
+			}
+			for _, s := range list {
+				if strings.EqualFold(s, v) {
+					return true
+				}
+			}
+			return false
+		}}, nil
+	case container.typ == TypeInts && element.typ == TypeInt:
+		return node{typ: TypeBool, pos: pos, eval: func(env Env) any {
+			v, ok := e(env).(int)
+			list, listOK := c(env).([]int)
+			if !ok || !listOK {
+				return nil
+			}
+			for _, n := range list {
+				if n == v {
+					return true
+				}
+			}
+			return false
+		}}, nil
+	default:
+		return node{}, errorf(pos, "%s can not contain %s", container.typ, element.typ)
+	}
+}
+
+// compileCompare compiles comparison, it is missing if any of the values is missing.
+func compileCompare(op token, left, right node) (node, error) {
+	l, r := left.eval, right.eval
+
+	if left.typ.isNumber() && right.typ.isNumber() {
+		cmp := func(env Env) (int, bool) {
+			a, b := l(env), r(env)
+			if a == nil || b == nil {
+				return 0, false
+			}
+			if ai, ok := a.(int); ok {
+				if bi, ok := b.(int); ok {
+					return compare(ai, bi), true
+				}
+			}
+			return compare(toFloat(a), toFloat(b)), true
+		}
+		return node{typ: TypeBool, pos: op.pos, eval: compareFunc(op.text, cmp)}, nil
+	}
+
+	if left.typ != right.typ {
+		return node{}, errorf(op.pos, "can not compare %s with %s", left.typ, right.typ)
+	}
+	if op.text != "==" && op.text != "!=" {
+		return node{}, errorf(op.pos, "%s can not be ordered", left.typ)
+	}
+
+	if left.typ != TypeBool && left.typ != TypeString {
+		return node{}, errorf(op.pos, "%s can not be compared", left.typ)
+	}
+	cmp := func(env Env) (int, bool) {
+		a, b := l(env), r(env)
+		if a == nil || b == nil {
+			return 0, false
+		}
+		if a == b {
+			return 0, true
+		}
+		return 1, true
+	}
+
+	return node{typ: TypeBool, pos: op.pos, eval: compareFunc(op.text, cmp)}, nil
+}
+
+func compareFunc(op string, cmp func(Env) (int, bool)) func(Env) any {
+	test := func(c int) bool { return c >= 0 }
+	switch op {
+	case "==":
+		test = func(c int) bool { return c == 0 }
+	case "!=":
+		test = func(c int) bool { return c != 0 }
+	case "<":
+		test = func(c int) bool { return c < 0 }
+	case "<=":
+		test = func(c int) bool { return c <= 0 }
+	case ">":
+		test = func(c int) bool { return c > 0 }
+	}
+
+	return func(env Env) any {
+		c, ok := cmp(env)
+		if !ok {
+			return nil
+		}
+		return test(c)
+	}
+}
+
+func compare[T int | float64](a, b T) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func toFloat(v any) float64 {
+	if i, ok := v.(int); ok {
+		return float64(i)
+	}
+
+	return v.(float64)
+}
+
+func (p *parser) parseOperand() (node, error) {
+	t := p.next()
+
+	switch {
+	case t.kind == tokenNumber:
+		return numberNode(t, false)
+	case t.kind == tokenPunct && t.text == "-":
+		number := p.next()
+		if number.kind != tokenNumber {
+			return node{}, errorf(number.pos, "expected number")
+		}
+		return numberNode(number, true)
+	case t.kind == tokenString:
+		return constNode(TypeString, t.text, t.pos), nil
+	case t.kind == tokenPunct && (t.text == "true" || t.text == "false"):
+		return constNode(TypeBool, t.text == "true", t.pos), nil
+	case t.kind == tokenPunct && t.text == "[":
+		return p.parseList(t)
+	case t.kind == tokenPunct && t.text == "(":
+		n, err := p.parseOr()
+		if err != nil {
+			return node{}, err
+		}
+		return n, p.expect(")")
+	case t.kind == tokenIdent && p.peek().kind == tokenPunct && p.peek().text == "(":
+		return p.parseCall(t)
+	case t.kind == tokenIdent:
+		typ, ok := p.cfg.Vars[t.text]
+		if !ok {
+			return node{}, errorf(t.pos, "unknown variable %q", t.text)
+		}
+		p.vars[t.text] = struct{}{}
+		name := t.text
+		return node{typ: typ, pos: t.pos, eval: func(env Env) any { return env.Get(name) }}, nil
+	case t.kind == tokenEOF:
+		return node{}, errorf(t.pos, "unexpected end of expression")
+	default:
+		return node{}, errorf(t.pos, "unexpected %q", t.text)
+	}
+}
+
+func constNode(typ Type, v any, pos int) node {
+	return node{typ: typ, pos: pos, eval: func(Env) any { return v }}
+}
+
+func numberNode(t token, negative bool) (node, error) {
+	if i, err := strconv.Atoi(t.text); err == nil {
+		if negative {
+			i = -i
+		}
+		return constNode(TypeInt, i, t.pos), nil
+	}
+
+	f, err := strconv.ParseFloat(t.text, 64)
+	if err != nil {
+		return node{}, errorf(t.pos, "invalid number %s", t.text)
+	}
+	if negative {
+		f = -f
+	}
+
+	return constNode(TypeFloat, f, t.pos), nil
+}
+
+// parseList parses list literal of strings or ints.
+func (p *parser) parseList(open token) (node, error) {
+	var (
+		strs []string
+		ints []int
+	)
+	for !p.accept("]") {
+		if len(strs)+len(ints) > 0 {
+			if err := p.expect(","); err != nil {
+				return node{}, err
+			}
+		}
+
+		t := p.next()
+		switch {
+		case t.kind == tokenString && len(ints) == 0:
+			strs = append(strs, t.text)
+			continue
+		case (t.kind == tokenNumber || t.text == "-") && len(strs) == 0:
+			negative := t.text == "-"
+			if negative {
+				t = p.next()
+			}
+			n, err := numberNode(t, negative)
+			if err != nil {
+				return node{}, err
+			}
+			if n.typ == TypeInt {
+				ints = append(ints, n.eval(nil).(int))
+				continue
+			}
+		case t.kind == tokenEOF:
+			return node{}, errorf(t.pos, "expected \"]\"")
+		}
+		return node{}, errorf(t.pos, "list elements must be string or int literals of the same type")
+	}
+
+	if len(ints) > 0 {
+		return constNode(TypeInts, ints, open.pos), nil
+	}
+	return constNode(TypeStrings, strs, open.pos), nil
+}
+
+func (p *parser) parseCall(name token) (node, error) {
+	p.next() // (
+
+	var args []node
+	for !p.accept(")") {
+		if len(args) > 0 {
+			if err := p.expect(","); err != nil {
+				return node{}, err
+			}
+		}
+		arg, err := p.parseOr()
+		if err != nil {
+			return node{}, err
+		}
+		args = append(args, arg)
+	}
+
+	if name.text == "len" {
+		return compileLen(name, args)
+	}
+
+	fn, ok := p.cfg.Funcs[name.text]
+	if !ok {
+		return node{}, errorf(name.pos, "unknown function %q", name.text)
+	}
+	if len(args) != len(fn.Args) {
+		return node{}, errorf(name.pos, "%s expects %d arguments, got %d", name.text, len(fn.Args), len(args))
+	}
+	for i, arg := range args {
+		if arg.typ != fn.Args[i] {
+			return node{}, errorf(arg.pos, "%s expects %s, got %s", name.text, fn.Args[i], arg.typ)
+		}
+	}
+
+	return node{typ: fn.Result, pos: name.pos, eval: func(env Env) any {
+		values := make([]any, len(args))
+		for i, arg := range args {
+			values[i] = arg.eval(env)
+			if values[i] == nil {
+				return nil
+			}
+		}
+		return fn.Fn(values)
+	}}, nil
+}
+
+// compileLen compiles built-in len of string in characters or list.
+func compileLen(name token, args []node) (node, error) {
+	if len(args) != 1 {
+		return node{}, errorf(name.pos, "len expects 1 argument, got %d", len(args))
+	}
+
+	eval := args[0].eval
+	arg := func(env Env) (any, bool) {
+		v := eval(env)
+		return v, v != nil
+	}
+	switch args[0].typ {
+	case TypeString:
+		return node{typ: TypeInt, pos: name.pos, eval: func(env Env) any {
+			if v, ok := arg(env); ok {
+				return utf8.RuneCountInString(v.(string))
+			}
+			return nil
+		}}, nil
+	case TypeStrings:
+		return node{typ: TypeInt, pos: name.pos, eval: func(env Env) any {
+			if v, ok := arg(env); ok {
+				return len(v.([]string))
+			}
+			return nil
+		}}, nil
+	case TypeInts:
+		return node{typ: TypeInt, pos: name.pos, eval: func(env Env) any {
+			if v, ok := arg(env); ok {
+				return len(v.([]int))
+			}
+			return nil
+		}}, nil
+	default:
+		return node{}, errorf(args[0].pos, "len expects string or list, got %s", args[0].typ)
+	}
+}
@@ -0,0 +1,101 @@
+package flood
+
+import (
+	"sync"
+	"time"
+
+	"github.com/hashicorp/golang-lru/v2/simplelru"
+)
+
+// shardCount is a number of independently locked shards,
+// so concurrent comments of different users do not contend on one lock.
+const shardCount = 32
+
+// Key identifies a sliding window.
+type Key struct {
+	// Rule is an index of the flood rule.
+	Rule   int
+	FromID int
+	// PostID is 0 when comments are counted across all posts.
+	PostID int
+}
+
+// Detector counts comments in sliding windows.
+// Memory is bounded, the least recently active windows are evicted.
+type Detector struct {
+	shards [shardCount]shard
+}
+
+type shard struct {
+	windows *simplelru.LRU[Key, []time.Time]
+	m       sync.Mutex
+}
+
+// New creates a new detector keeping at most size windows.
+func New(size int) *Detector {
+	shardSize := size / shardCount
+	if shardSize < 1 {
+		shardSize = 1
+	}
+
+	d := &Detector{}
+	for i := range d.shards {
+		windows, err := simplelru.NewLRU[Key, []time.Time](shardSize, nil)
+		if err != nil {
+			panic(err)
+		}
+		d.shards[i].windows = windows
+	}
+
+	return d
+}
+
+// Hit records comment at the given time and returns number of comments in the window,
+// including the recorded one. At most limit+1 comments are kept per window.
+func (d *Detector) Hit(key Key, now time.Time, window time.Duration, limit int) int {
+	s := d.shard(key)
+
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	hits, _ := s.windows.Get(key)
+	hits = slide(hits, now, window, limit)
+	s.windows.Add(key, hits)
+
+	return len(hits)
+}
+
+// Count returns number of comments in the window as if comment were recorded at the given time,
+// the window is not changed.
+func (d *Detector) Count(key Key, now time.Time, window time.Duration, limit int) int {
+	s := d.shard(key)
+
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	hits, _ := s.windows.Peek(key)
+	// The window is copied, so the kept one is not overwritten.
+	hits = slide(append([]time.Time(nil), hits...), now, window, limit)
+
+	return len(hits)
+}
+
+func (d *Detector) shard(key Key) *shard {
+	// Windows of one author are kept in one shard.
+	return &d.shards[uint(key.FromID)%shardCount]
+}
+
+// slide drops comments which are out of the window and appends the new one.
+func slide(hits []time.Time, now time.Time, window time.Duration, limit int) []time.Time {
+	from := now.Add(-window)
+	i := 0
+	for i < len(hits) && !hits[i].After(from) {
+		i++
+	}
+	hits = append(hits[i:], now)
+	if len(hits) > limit+1 {
+		hits = hits[len(hits)-limit-1:]
+	}
+
+	return hits
+}
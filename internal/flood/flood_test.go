@@ -0,0 +1,93 @@
+package flood
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDetector_Hit(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		offset []time.Duration
+		want   int
+	}{
+		{
+			name:   "single comment",
+			offset: []time.Duration{0},
+			want:   1,
+		},
+		{
+			name:   "comments in window",
+			offset: []time.Duration{0, 10 * time.Second, 20 * time.Second},
+			want:   3,
+		},
+		{
+			name:   "old comments are dropped",
+			offset: []time.Duration{0, 10 * time.Second, 65 * time.Second},
+			want:   2,
+		},
+		{
+			name:   "comments above limit are not kept",
+			offset: []time.Duration{0, time.Second, 2 * time.Second, 3 * time.Second, 4 * time.Second},
+			want:   4,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			d := New(100)
+			var got int
+			for _, offset := range tt.offset {
+				got = d.Hit(Key{FromID: 1}, now.Add(offset), time.Minute, 3)
+			}
+			if got != tt.want {
+				t.Errorf("Hit() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDetector_Count(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	d := New(100)
+	d.Hit(Key{FromID: 1}, now, time.Minute, 3)
+
+	// Counted comments are not recorded.
+	for i := 0; i < 3; i++ {
+		if got := d.Count(Key{FromID: 1}, now.Add(time.Second), time.Minute, 3); got != 2 {
+			t.Errorf("Count() got = %v, want %v", got, 2)
+		}
+	}
+	if got := d.Hit(Key{FromID: 1}, now.Add(time.Second), time.Minute, 3); got != 2 {
+		t.Errorf("Hit() got = %v, want %v", got, 2)
+	}
+}
+
+func TestDetector_Keys(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	d := New(100)
+
+	d.Hit(Key{FromID: 1, PostID: 1}, now, time.Minute, 3)
+	if got := d.Hit(Key{FromID: 1, PostID: 2}, now, time.Minute, 3); got != 1 {
+		t.Errorf("Hit() other post got = %v, want %v", got, 1)
+	}
+	if got := d.Hit(Key{FromID: 2, PostID: 1}, now, time.Minute, 3); got != 1 {
+		t.Errorf("Hit() other user got = %v, want %v", got, 1)
+	}
+	if got := d.Hit(Key{Rule: 1, FromID: 1, PostID: 1}, now, time.Minute, 3); got != 1 {
+		t.Errorf("Hit() other rule got = %v, want %v", got, 1)
+	}
+	if got := d.Hit(Key{FromID: 1, PostID: 1}, now, time.Minute, 3); got != 2 {
+		t.Errorf("Hit() same key got = %v, want %v", got, 2)
+	}
+}
@@ -0,0 +1,252 @@
+package langdetect
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Script is a writing system of letters.
+type Script string
+
+// Detected scripts.
+const (
+	ScriptCyrillic   Script = "cyrillic"
+	ScriptLatin      Script = "latin"
+	ScriptGreek      Script = "greek"
+	ScriptArabic     Script = "arabic"
+	ScriptHebrew     Script = "hebrew"
+	ScriptHan        Script = "han"
+	ScriptKana       Script = "kana"
+	ScriptHangul     Script = "hangul"
+	ScriptDevanagari Script = "devanagari"
+	ScriptGeorgian   Script = "georgian"
+	ScriptArmenian   Script = "armenian"
+	ScriptThai       Script = "thai"
+	ScriptOther      Script = "other"
+)
+
+// scriptTables contains Unicode tables of detected scripts, other letters are counted as ScriptOther.
+var scriptTables = []struct {
+	script Script
+	tables []*unicode.RangeTable
+}{
+	{ScriptCyrillic, []*unicode.RangeTable{unicode.Cyrillic}},
+	{ScriptLatin, []*unicode.RangeTable{unicode.Latin}},
+	{ScriptGreek, []*unicode.RangeTable{unicode.Greek}},
+	{ScriptArabic, []*unicode.RangeTable{unicode.Arabic}},
+	{ScriptHebrew, []*unicode.RangeTable{unicode.Hebrew}},
+	{ScriptHan, []*unicode.RangeTable{unicode.Han}},
+	{ScriptKana, []*unicode.RangeTable{unicode.Hiragana, unicode.Katakana}},
+	{ScriptHangul, []*unicode.RangeTable{unicode.Hangul}},
+	{ScriptDevanagari, []*unicode.RangeTable{unicode.Devanagari}},
+	{ScriptGeorgian, []*unicode.RangeTable{unicode.Georgian}},
+	{ScriptArmenian, []*unicode.RangeTable{unicode.Armenian}},
+	{ScriptThai, []*unicode.RangeTable{unicode.Thai}},
+}
+
+// scriptLanguages maps scripts used by a single language to the language.
+var scriptLanguages = map[Script]string{
+	ScriptGreek:      "el",
+	ScriptArabic:     "ar",
+	ScriptHebrew:     "he",
+	ScriptHan:        "zh",
+	ScriptKana:       "ja",
+	ScriptHangul:     "ko",
+	ScriptDevanagari: "hi",
+	ScriptGeorgian:   "ka",
+	ScriptArmenian:   "hy",
+	ScriptThai:       "th",
+}
+
+// Languages contains ISO 639-1 codes of detected languages.
+var Languages []string
+
+// maxN is the longest n-gram of the models.
+const maxN = 3
+
+// smoothing is added to n-gram counts, so unseen n-grams do not rule out a language.
+const smoothing = 0.1
+
+var (
+	// models contains n-gram models of languages detected by n-grams.
+	models = make(map[Script][]model)
+	// vocabularies contains numbers of distinct n-grams of each length in models of each script.
+	vocabularies = make(map[Script][maxN + 1]int)
+)
+
+type model struct {
+	language string
+	// alphabet contains letters of the language, any letters are allowed if it is empty.
+	alphabet string
+	counts   map[string]int
+	// totals contains numbers of n-grams of each length.
+	totals [maxN + 1]int
+}
+
+func init() {
+	vocabulary := make(map[Script]map[string]struct{})
+	for language, sample := range samples {
+		m := model{language: language, alphabet: sample.alphabet, counts: ngrams(sample.text)}
+		if vocabulary[sample.script] == nil {
+			vocabulary[sample.script] = make(map[string]struct{})
+		}
+		for gram, c := range m.counts {
+			m.totals[utf8.RuneCountInString(gram)] += c
+			vocabulary[sample.script][gram] = struct{}{}
+		}
+		models[sample.script] = append(models[sample.script], m)
+		Languages = append(Languages, language)
+	}
+	for _, language := range scriptLanguages {
+		Languages = append(Languages, language)
+	}
+	sort.Strings(Languages)
+	for script, grams := range vocabulary {
+		var sizes [maxN + 1]int
+		for gram := range grams {
+			sizes[utf8.RuneCountInString(gram)]++
+		}
+		vocabularies[script] = sizes
+	}
+	// Models are sorted, so ties are resolved the same way on every run.
+	for _, m := range models {
+		sort.Slice(m, func(i, j int) bool { return m[i].language < m[j].language })
+	}
+}
+
+// Result is a result of language detection.
+type Result struct {
+	// Language is an ISO 639-1 code of the language, it is empty if the language is not known.
+	Language string
+	// Letters is a number of letters in the text.
+	Letters int
+	// Scripts contains numbers of letters of each script.
+	Scripts map[Script]int
+}
+
+// Share returns share of letters of the script, from 0 to 1.
+func (r Result) Share(script Script) float64 {
+	if r.Letters == 0 {
+		return 0
+	}
+
+	return float64(r.Scripts[script]) / float64(r.Letters)
+}
+
+// Detect detects language and scripts of text.
+// Language is detected by the script with most letters,
+// Cyrillic and Latin languages are told apart by n-gram models.
+func Detect(text string) Result {
+	res := Result{Scripts: make(map[Script]int)}
+	for _, r := range text {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		res.Letters++
+		res.Scripts[scriptOf(r)]++
+	}
+	if res.Letters == 0 {
+		return res
+	}
+
+	script := ScriptOther
+	for _, s := range scriptTables {
+		if res.Scripts[s.script] > res.Scripts[script] {
+			script = s.script
+		}
+	}
+	// Japanese mixes kana with Han characters.
+	if script == ScriptHan && res.Scripts[ScriptKana] > 0 {
+		script = ScriptKana
+	}
+
+	if language, ok := scriptLanguages[script]; ok {
+		res.Language = language
+		return res
+	}
+	res.Language = detectByNGrams(text, withAlphabet(text, script, models[script]), vocabularies[script])
+
+	return res
+}
+
+func scriptOf(r rune) Script {
+	for _, s := range scriptTables {
+		if unicode.In(r, s.tables...) {
+			return s.script
+		}
+	}
+
+	return ScriptOther
+}
+
+// withAlphabet returns models of languages with all letters of text in their alphabets.
+// Only letters of the script of the alphabets are checked, other ones may come from foreign words.
+// All models are returned if none of them fits, text may contain typos.
+func withAlphabet(text string, script Script, candidates []model) []model {
+	text = strings.ToLower(text)
+
+	var fit []model
+	for _, m := range candidates {
+		foreign := strings.IndexFunc(text, func(r rune) bool {
+			return scriptOf(r) == script && !strings.ContainsRune(m.alphabet, r)
+		})
+		if m.alphabet == "" || foreign < 0 {
+			fit = append(fit, m)
+		}
+	}
+	if len(fit) == 0 {
+		return candidates
+	}
+
+	return fit
+}
+
+// detectByNGrams returns language of the model with the highest probability of text n-grams.
+func detectByNGrams(text string, candidates []model, vocabulary [maxN + 1]int) string {
+	grams := ngrams(text)
+	if len(grams) == 0 {
+		return ""
+	}
+
+	var (
+		best      string
+		bestScore = math.Inf(-1)
+	)
+	for _, m := range candidates {
+		score := 0.0
+		for gram, count := range grams {
+			n := utf8.RuneCountInString(gram)
+			p := (float64(m.counts[gram]) + smoothing) / (float64(m.totals[n]) + smoothing*float64(vocabulary[n]))
+			score += float64(count) * math.Log(p)
+		}
+		if score > bestScore {
+			best, bestScore = m.language, score
+		}
+	}
+
+	return best
+}
+
+// ngrams counts letter n-grams up to maxN of lowercase words padded with spaces.
+func ngrams(text string) map[string]int {
+	counts := make(map[string]int)
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && r != '\''
+	})
+	for _, word := range words {
+		runes := []rune(" " + word + " ")
+		for n := 1; n <= maxN; n++ {
+			for i := 0; i+n <= len(runes); i++ {
+				if n == 1 && runes[i] == ' ' {
+					continue
+				}
+				counts[string(runes[i:i+n])]++
+			}
+		}
+	}
+
+	return counts
+}
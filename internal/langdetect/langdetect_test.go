@@ -0,0 +1,103 @@
+package langdetect
+
+import (
+	"math"
+	"testing"
+)
+
+func TestDetect(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{
+			name: "russian",
+			text: "Подскажите, пожалуйста, где можно купить билеты на концерт в субботу?",
+			want: "ru",
+		},
+		{
+			name: "ukrainian",
+			text: "Підкажіть, будь ласка, де можна купити квитки на концерт у суботу?",
+			want: "uk",
+		},
+		{
+			name: "short ukrainian",
+			text: "Слава Україні, героям слава",
+			want: "uk",
+		},
+		{
+			name: "belarusian",
+			text: "Падкажыце, калі ласка, дзе можна купіць білеты на канцэрт у суботу?",
+			want: "be",
+		},
+		{
+			name: "bulgarian",
+			text: "Кажете ми, моля, къде мога да си купя билети за концерта в събота?",
+			want: "bg",
+		},
+		{
+			name: "english",
+			text: "Could you please tell me where I can buy tickets for the concert on Saturday?",
+			want: "en",
+		},
+		{
+			name: "german",
+			text: "Können Sie mir bitte sagen, wo ich Karten für das Konzert am Samstag kaufen kann?",
+			want: "de",
+		},
+		{
+			name: "spanish",
+			text: "¿Podría decirme dónde puedo comprar las entradas para el concierto del sábado?",
+			want: "es",
+		},
+		{
+			name: "chinese",
+			text: "请问星期六的音乐会门票在哪里可以买到？",
+			want: "zh",
+		},
+		{
+			name: "japanese",
+			text: "土曜日のコンサートのチケットはどこで買えますか？",
+			want: "ja",
+		},
+		{
+			name: "arabic",
+			text: "هل يمكنك أن تخبرني أين يمكنني شراء تذاكر الحفلة يوم السبت؟",
+			want: "ar",
+		},
+		{
+			name: "no letters",
+			text: "5000 :) !!!",
+			want: "",
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := Detect(tt.text).Language; got != tt.want {
+				t.Errorf("Detect() language = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResultShare(t *testing.T) {
+	t.Parallel()
+
+	res := Detect("Привет, hello! 123")
+	if res.Letters != 11 {
+		t.Errorf("Detect() letters = %v, want %v", res.Letters, 11)
+	}
+	if got := res.Share(ScriptCyrillic); math.Abs(got-6.0/11) > 1e-9 {
+		t.Errorf("Share() = %v, want %v", got, 6.0/11)
+	}
+	if got := (Result{}).Share(ScriptLatin); got != 0 {
+		t.Errorf("Share() = %v, want %v", got, 0)
+	}
+}
@@ -0,0 +1,116 @@
+package langdetect
+
+// samples contains texts the n-gram models of languages are built from.
+// Languages are detected among the ones written in the same script.
+// Cyrillic alphabets differ in a few letters, which are decisive in short texts,
+// so languages with other letters in the text are not considered.
+var samples = map[string]struct {
+	script   Script
+	alphabet string
+	text     string
+}{
+	"ru": {ScriptCyrillic, "абвгдеёжзийклмнопрстуфхцчшщъыьэюя", `Вчера вечером мы долго гуляли по старому городу и разговаривали о жизни.
+Погода была хорошая, хотя к ночи стало прохладно и пошёл небольшой дождь.
+Мне кажется, что это один из самых красивых районов, которые я когда-либо видел.
+Спасибо автору за интересную статью, было очень приятно читать про историю этих мест.
+Надеюсь, что в следующий раз вы расскажете ещё больше подробностей о людях, которые здесь жили.
+Дети играли во дворе, а соседи обсуждали новости и цены в магазинах.
+Если вы хотите узнать больше, пишите в комментариях, мы обязательно ответим на все вопросы.
+Это действительно важная тема, и каждый должен подумать о своём будущем и будущем своей семьи.`},
+	"uk": {ScriptCyrillic, "абвгґдеєжзиіїйклмнопрстуфхцчшщьюя", `Учора ввечері ми довго гуляли старим містом і розмовляли про життя.
+Погода була гарна, хоча до ночі стало прохолодно і пішов невеликий дощ.
+Мені здається, що це один із найкрасивіших районів, які я коли-небудь бачив.
+Дякую авторові за цікаву статтю, було дуже приємно читати про історію цих місць.
+Сподіваюся, що наступного разу ви розповісте ще більше подробиць про людей, які тут жили.
+Діти гралися на подвір'ї, а сусіди обговорювали новини та ціни в крамницях.
+Якщо ви хочете дізнатися більше, пишіть у коментарях, ми обов'язково відповімо на всі запитання.
+Це справді важлива тема, і кожен повинен подумати про своє майбутнє та майбутнє своєї родини.`},
+	"be": {ScriptCyrillic, "абвгдеёжзійклмнопрстуўфхцчшыьэюя", `Учора ўвечары мы доўга гулялі па старым горадзе і размаўлялі пра жыццё.
+Надвор'е было добрае, хоць да ночы стала халаднавата і пайшоў невялікі дождж.
+Мне здаецца, што гэта адзін з самых прыгожых раёнаў, якія я калі-небудзь бачыў.
+Дзякуй аўтару за цікавы артыкул, было вельмі прыемна чытаць пра гісторыю гэтых мясцін.
+Спадзяюся, што наступным разам вы раскажаце яшчэ больш падрабязнасцей пра людзей, якія тут жылі.
+Дзеці гулялі ў двары, а суседзі абмяркоўвалі навіны і цэны ў крамах.
+Калі вы хочаце даведацца больш, пішыце ў каментарах, мы абавязкова адкажам на ўсе пытанні.
+Гэта сапраўды важная тэма, і кожны павінен падумаць пра сваю будучыню і будучыню сваёй сям'і.`},
+	"bg": {ScriptCyrillic, "абвгдежзийклмнопрстуфхцчшщъьюя", `Вчера вечерта дълго се разхождахме из стария град и си говорихме за живота.
+Времето беше хубаво, въпреки че към нощта стана хладно и заваля слаб дъжд.
+Струва ми се, че това е един от най-красивите квартали, които някога съм виждал.
+Благодаря на автора за интересната статия, беше много приятно да чета за историята на тези места.
+Надявам се следващия път да разкажете още повече подробности за хората, които са живели тук.
+Децата играеха в двора, а съседите обсъждаха новините и цените в магазините.
+Ако искате да научите повече, пишете в коментарите, ние непременно ще отговорим на всички въпроси.
+Това наистина е важна тема и всеки трябва да помисли за своето бъдеще и бъдещето на семейството си.`},
+	"sr": {ScriptCyrillic, "абвгдђежзијклљмнњопрстћуфхцчџш", `Јуче увече смо дуго шетали старим градом и разговарали о животу.
+Време је било лепо, иако је пред ноћ постало свеже и почела је да пада слаба киша.
+Чини ми се да је ово један од најлепших крајева које сам икада видео.
+Хвала аутору на занимљивом чланку, било је веома пријатно читати о историји ових места.
+Надам се да ћете следећи пут испричати још више детаља о људима који су овде живели.
+Деца су се играла у дворишту, а комшије су разговарале о вестима и ценама у продавницама.
+Ако желите да сазнате више, пишите у коментарима, обавезно ћемо одговорити на сва питања.
+Ово је заиста важна тема и свако треба да размисли о својој будућности и будућности своје породице.`},
+	"en": {ScriptLatin, "", `Yesterday evening we walked around the old town for a long time and talked about life.
+The weather was nice, although it got cool towards the night and a light rain started.
+It seems to me that this is one of the most beautiful neighbourhoods I have ever seen.
+Thanks to the author for the interesting article, it was a pleasure to read about the history of these places.
+I hope that next time you will tell us even more details about the people who lived here.
+The children were playing in the yard while the neighbours discussed the news and the prices in the shops.
+If you want to know more, write in the comments and we will definitely answer all your questions.
+This is a really important topic, and everyone should think about their future and the future of their family.`},
+	"de": {ScriptLatin, "", `Gestern Abend sind wir lange durch die Altstadt spaziert und haben über das Leben gesprochen.
+Das Wetter war schön, obwohl es gegen Nacht kühl wurde und ein leichter Regen einsetzte.
+Mir scheint, dass dies eines der schönsten Viertel ist, die ich je gesehen habe.
+Danke an den Autor für den interessanten Artikel, es war sehr angenehm, über die Geschichte dieser Orte zu lesen.
+Ich hoffe, dass Sie uns beim nächsten Mal noch mehr Einzelheiten über die Menschen erzählen, die hier gelebt haben.
+Die Kinder spielten im Hof, während die Nachbarn die Nachrichten und die Preise in den Geschäften besprachen.
+Wenn Sie mehr erfahren möchten, schreiben Sie in die Kommentare, wir werden auf jeden Fall alle Fragen beantworten.
+Das ist wirklich ein wichtiges Thema, und jeder sollte über seine Zukunft und die Zukunft seiner Familie nachdenken.`},
+	"fr": {ScriptLatin, "", `Hier soir, nous nous sommes longtemps promenés dans la vieille ville en parlant de la vie.
+Il faisait beau, même si vers la nuit il a commencé à faire frais et une petite pluie est tombée.
+Il me semble que c'est l'un des plus beaux quartiers que j'aie jamais vus.
+Merci à l'auteur pour cet article intéressant, c'était très agréable de lire l'histoire de ces lieux.
+J'espère que la prochaine fois vous nous raconterez encore plus de détails sur les gens qui ont vécu ici.
+Les enfants jouaient dans la cour pendant que les voisins discutaient des nouvelles et des prix dans les magasins.
+Si vous voulez en savoir plus, écrivez dans les commentaires, nous répondrons certainement à toutes vos questions.
+C'est vraiment un sujet important, et chacun devrait penser à son avenir et à l'avenir de sa famille.`},
+	"es": {ScriptLatin, "", `Ayer por la tarde paseamos mucho tiempo por el casco antiguo y hablamos de la vida.
+Hacía buen tiempo, aunque hacia la noche refrescó y empezó a caer una lluvia ligera.
+Me parece que es uno de los barrios más bonitos que he visto nunca.
+Gracias al autor por el artículo tan interesante, fue muy agradable leer sobre la historia de estos lugares.
+Espero que la próxima vez nos cuente todavía más detalles sobre las personas que vivieron aquí.
+Los niños jugaban en el patio mientras los vecinos comentaban las noticias y los precios de las tiendas.
+Si quiere saber más, escriba en los comentarios, sin duda responderemos a todas sus preguntas.
+Es un tema realmente importante y cada uno debería pensar en su futuro y en el futuro de su familia.`},
+	"it": {ScriptLatin, "", `Ieri sera abbiamo passeggiato a lungo per il centro storico e abbiamo parlato della vita.
+Il tempo era bello, anche se verso notte ha fatto fresco ed è cominciata a cadere una pioggia leggera.
+Mi sembra che questo sia uno dei quartieri più belli che abbia mai visto.
+Grazie all'autore per l'articolo interessante, è stato molto piacevole leggere la storia di questi luoghi.
+Spero che la prossima volta ci racconterete ancora più dettagli sulle persone che hanno vissuto qui.
+I bambini giocavano nel cortile mentre i vicini discutevano delle notizie e dei prezzi nei negozi.
+Se volete saperne di più, scrivete nei commenti, risponderemo sicuramente a tutte le vostre domande.
+Questo è davvero un tema importante e ognuno dovrebbe pensare al proprio futuro e al futuro della propria famiglia.`},
+	"pt": {ScriptLatin, "", `Ontem à noite passeamos muito tempo pela cidade velha e conversamos sobre a vida.
+O tempo estava bom, embora perto da noite tenha ficado fresco e começado uma chuva fraca.
+Parece-me que este é um dos bairros mais bonitos que eu já vi.
+Obrigado ao autor pelo artigo interessante, foi muito agradável ler sobre a história destes lugares.
+Espero que da próxima vez vocês contem ainda mais detalhes sobre as pessoas que viveram aqui.
+As crianças brincavam no quintal enquanto os vizinhos discutiam as notícias e os preços nas lojas.
+Se quiserem saber mais, escrevam nos comentários, com certeza responderemos a todas as perguntas.
+Este é realmente um assunto importante e cada um deveria pensar no seu futuro e no futuro da sua família.`},
+	"pl": {ScriptLatin, "", `Wczoraj wieczorem długo spacerowaliśmy po starym mieście i rozmawialiśmy o życiu.
+Pogoda była ładna, chociaż pod wieczór zrobiło się chłodno i zaczął padać lekki deszcz.
+Wydaje mi się, że to jedna z najpiękniejszych dzielnic, jakie kiedykolwiek widziałem.
+Dziękuję autorowi za ciekawy artykuł, bardzo przyjemnie czytało się o historii tych miejsc.
+Mam nadzieję, że następnym razem opowiecie jeszcze więcej szczegółów o ludziach, którzy tu mieszkali.
+Dzieci bawiły się na podwórku, a sąsiedzi rozmawiali o wiadomościach i cenach w sklepach.
+Jeśli chcecie dowiedzieć się więcej, piszcie w komentarzach, na pewno odpowiemy na wszystkie pytania.
+To naprawdę ważny temat i każdy powinien pomyśleć o swojej przyszłości i przyszłości swojej rodziny.`},
+	"tr": {ScriptLatin, "", `Dün akşam eski şehirde uzun uzun yürüdük ve hayat hakkında konuştuk.
+Hava güzeldi, ancak geceye doğru serinledi ve hafif bir yağmur başladı.
+Bana öyle geliyor ki bu, şimdiye kadar gördüğüm en güzel semtlerden biri.
+Bu ilginç makale için yazara teşekkürler, bu yerlerin tarihini okumak çok keyifliydi.
+Umarım bir dahaki sefere burada yaşamış insanlar hakkında daha fazla ayrıntı anlatırsınız.
+Çocuklar bahçede oynarken komşular haberleri ve dükkanlardaki fiyatları konuşuyordu.
+Daha fazlasını öğrenmek istiyorsanız yorumlara yazın, tüm sorularınızı mutlaka yanıtlayacağız.
+Bu gerçekten önemli bir konu ve herkes kendi geleceğini ve ailesinin geleceğini düşünmeli.`},
+}
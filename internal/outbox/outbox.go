@@ -0,0 +1,292 @@
+package outbox
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sklyar/vk-banhammer/internal/entity"
+	"github.com/sklyar/vk-banhammer/internal/jsonfile"
+)
+
+// minCompactRecords is the number of log records below which the log is never compacted.
+const minCompactRecords = 1000
+
+// Outbox keeps pending moderation actions.
+// Changes are appended to a log file, so actions survive restarts.
+// The log is compacted on start and whenever it grows twice as large as the pending actions.
+// If path is empty, actions are kept in memory only.
+type Outbox struct {
+	path    string
+	f       *os.File
+	records int
+	actions map[string]entity.Action
+	m       sync.Mutex
+}
+
+// record is a line of the outbox log, it either stores or deletes an action.
+type record struct {
+	Action  *entity.Action `json:"action,omitempty"`
+	Deleted string         `json:"deleted,omitempty"`
+}
+
+// New creates a new outbox and loads actions stored in the file.
+func New(path string) (*Outbox, error) {
+	o := &Outbox{
+		path:    path,
+		actions: make(map[string]entity.Action),
+	}
+
+	if err := o.load(); err != nil {
+		return nil, fmt.Errorf("failed to load outbox: %w", err)
+	}
+	if o.path != "" {
+		if err := o.compact(); err != nil {
+			return nil, fmt.Errorf("failed to compact outbox: %w", err)
+		}
+	}
+
+	return o, nil
+}
+
+// Put adds or replaces actions, they are written to the log at once.
+func (o *Outbox) Put(actions ...entity.Action) error {
+	o.m.Lock()
+	defer o.m.Unlock()
+
+	prev := make(map[string]*entity.Action, len(actions))
+	records := make([]record, 0, len(actions))
+	for _, action := range actions {
+		action := action
+		if _, ok := prev[action.ID]; !ok {
+			if p, exists := o.actions[action.ID]; exists {
+				prev[action.ID] = &p
+			} else {
+				prev[action.ID] = nil
+			}
+		}
+		o.actions[action.ID] = action
+		records = append(records, record{Action: &action})
+	}
+
+	if err := o.append(records...); err != nil {
+		for id, p := range prev {
+			if p != nil {
+				o.actions[id] = *p
+			} else {
+				delete(o.actions, id)
+			}
+		}
+		return err
+	}
+
+	return nil
+}
+
+// Delete removes action.
+func (o *Outbox) Delete(id string) error {
+	o.m.Lock()
+	defer o.m.Unlock()
+
+	prev, exists := o.actions[id]
+	if !exists {
+		return nil
+	}
+	delete(o.actions, id)
+
+	if err := o.append(record{Deleted: id}); err != nil {
+		o.actions[id] = prev
+		return err
+	}
+
+	return nil
+}
+
+// Due returns actions which should be attempted at the given time.
+// Actions are ordered by creation time.
+func (o *Outbox) Due(now time.Time) []entity.Action {
+	o.m.Lock()
+	defer o.m.Unlock()
+
+	var actions []entity.Action
+	for _, a := range o.actions {
+		if !a.NextAttemptAt.After(now) {
+			actions = append(actions, a)
+		}
+	}
+	sortActions(actions)
+
+	return actions
+}
+
+// Len returns number of pending actions.
+func (o *Outbox) Len() int {
+	o.m.Lock()
+	defer o.m.Unlock()
+
+	return len(o.actions)
+}
+
+// Close closes the log file.
+func (o *Outbox) Close() error {
+	o.m.Lock()
+	defer o.m.Unlock()
+
+	if o.f == nil {
+		return nil
+	}
+	err := o.f.Close()
+	o.f = nil
+
+	return err
+}
+
+// load replays the log. A torn last line, left by a crash during write, is ignored.
+// The file written by earlier versions as a JSON array is loaded as well.
+func (o *Outbox) load() error {
+	if o.path == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(o.path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '[' {
+		var actions []entity.Action
+		if err := json.Unmarshal(trimmed, &actions); err != nil {
+			return fmt.Errorf("failed to unmarshal %s: %w", o.path, err)
+		}
+		for _, a := range actions {
+			o.actions[a.ID] = a
+		}
+		return nil
+	}
+
+	lines := bytes.Split(data, []byte{'\n'})
+	for i, line := range lines {
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+
+		var r record
+		if err := json.Unmarshal(line, &r); err != nil {
+			if i == len(lines)-1 {
+				break
+			}
+			return fmt.Errorf("failed to unmarshal %s line %d: %w", o.path, i+1, err)
+		}
+
+		switch {
+		case r.Action != nil:
+			o.actions[r.Action.ID] = *r.Action
+		case r.Deleted != "":
+			delete(o.actions, r.Deleted)
+		}
+	}
+
+	return nil
+}
+
+// append writes records to the log and syncs it.
+// If the log is not open, it is rewritten from the pending actions instead.
+func (o *Outbox) append(records ...record) error {
+	if o.path == "" {
+		return nil
+	}
+	if o.f == nil {
+		return o.compact()
+	}
+
+	var buf bytes.Buffer
+	for _, r := range records {
+		data, err := json.Marshal(r)
+		if err != nil {
+			return fmt.Errorf("failed to marshal outbox record: %w", err)
+		}
+		buf.Write(data)
+		buf.WriteByte('\n')
+	}
+
+	if _, err := o.f.Write(buf.Bytes()); err != nil {
+		o.closeBroken()
+		return fmt.Errorf("failed to write outbox: %w", err)
+	}
+	if err := o.f.Sync(); err != nil {
+		o.closeBroken()
+		return fmt.Errorf("failed to sync outbox: %w", err)
+	}
+	o.records += len(records)
+
+	if o.records > minCompactRecords && o.records > 2*len(o.actions) {
+		// The records are already stored, the log is compacted again on the next change.
+		if err := o.compact(); err != nil {
+			o.closeBroken()
+		}
+	}
+
+	return nil
+}
+
+// closeBroken closes the log after a failed write,
+// so the next change rewrites it instead of appending after a partial line.
+func (o *Outbox) closeBroken() {
+	if o.f != nil {
+		_ = o.f.Close()
+		o.f = nil
+	}
+}
+
+// compact rewrites the log with the pending actions only and reopens it for appending.
+func (o *Outbox) compact() error {
+	actions := make([]entity.Action, 0, len(o.actions))
+	for _, a := range o.actions {
+		actions = append(actions, a)
+	}
+	sortActions(actions)
+
+	var buf bytes.Buffer
+	for i := range actions {
+		data, err := json.Marshal(record{Action: &actions[i]})
+		if err != nil {
+			return fmt.Errorf("failed to marshal outbox record: %w", err)
+		}
+		buf.Write(data)
+		buf.WriteByte('\n')
+	}
+
+	if err := jsonfile.WriteFile(o.path, buf.Bytes()); err != nil {
+		return err
+	}
+
+	if o.f != nil {
+		_ = o.f.Close()
+		o.f = nil
+	}
+	f, err := os.OpenFile(o.path, os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open outbox: %w", err)
+	}
+	o.f = f
+	o.records = len(actions)
+
+	return nil
+}
+
+func sortActions(actions []entity.Action) {
+	sort.Slice(actions, func(i, j int) bool {
+		if actions[i].CreatedAt.Equal(actions[j].CreatedAt) {
+			return actions[i].ID < actions[j].ID
+		}
+		return actions[i].CreatedAt.Before(actions[j].CreatedAt)
+	})
+}
@@ -0,0 +1,184 @@
+package outbox
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sklyar/vk-banhammer/internal/entity"
+)
+
+func TestOutbox_SurvivesRestart(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "outbox.json")
+	now := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	o, err := New(path)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	ban := entity.NewBanAction(-61061413, 87524863, entity.BanReasonPersonNonGrata, now)
+	del := entity.NewDeleteCommentAction(-61061413, 1, now.Add(time.Second))
+	if err := o.Put(ban, del); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	if err := o.Delete(ban.ID); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if err := o.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	o, err = New(path)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	got := o.Due(now.Add(time.Minute))
+	if len(got) != 1 {
+		t.Fatalf("Due() got %d actions, want 1", len(got))
+	}
+	if got[0].ID != del.ID || got[0].CommentID != del.CommentID {
+		t.Errorf("Due() got = %+v, want %+v", got[0], del)
+	}
+}
+
+func TestOutbox_Due(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	o, err := New("")
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	early := entity.NewDeleteCommentAction(-61061413, 1, now)
+	late := entity.NewDeleteCommentAction(-61061413, 2, now)
+	late.NextAttemptAt = now.Add(time.Hour)
+	for _, a := range []entity.Action{late, early} {
+		if err := o.Put(a); err != nil {
+			t.Fatalf("Put() error = %v", err)
+		}
+	}
+
+	got := o.Due(now)
+	if len(got) != 1 || got[0].ID != early.ID {
+		t.Errorf("Due() got = %+v, want only %s", got, early.ID)
+	}
+	if o.Len() != 2 {
+		t.Errorf("Len() got = %d, want 2", o.Len())
+	}
+}
+
+func TestOutbox_Compact(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "outbox.json")
+	now := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	o, err := New(path)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer o.Close() //nolint:errcheck
+
+	kept := entity.NewDeleteCommentAction(-61061413, 1, now)
+	if err := o.Put(kept); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	for i := 2; i < 2+minCompactRecords; i++ {
+		a := entity.NewDeleteCommentAction(-61061413, i, now)
+		if err := o.Put(a); err != nil {
+			t.Fatalf("Put() error = %v", err)
+		}
+		if err := o.Delete(a.ID); err != nil {
+			t.Fatalf("Delete() error = %v", err)
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	if lines := strings.Count(string(data), "\n"); lines > minCompactRecords {
+		t.Errorf("log has %d lines, want at most %d", lines, minCompactRecords)
+	}
+
+	o, err = New(path)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer o.Close() //nolint:errcheck
+
+	got := o.Due(now)
+	if len(got) != 1 || got[0].ID != kept.ID {
+		t.Errorf("Due() got = %+v, want only %s", got, kept.ID)
+	}
+}
+
+func TestOutbox_Load(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		data    string
+		want    []string
+		wantErr bool
+	}{
+		{
+			name: "json array",
+			data: `[{"id":"ban:1","type":"ban"},{"id":"delete:2","type":"delete_comment"}]`,
+			want: []string{"ban:1", "delete:2"},
+		},
+		{
+			name: "log",
+			data: `{"action":{"id":"ban:1","type":"ban"}}` + "\n" +
+				`{"action":{"id":"delete:2","type":"delete_comment"}}` + "\n" +
+				`{"deleted":"ban:1"}` + "\n",
+			want: []string{"delete:2"},
+		},
+		{
+			name: "torn last line",
+			data: `{"action":{"id":"ban:1","type":"ban"}}` + "\n" + `{"action":{"id":"del`,
+			want: []string{"ban:1"},
+		},
+		{
+			name:    "broken line",
+			data:    `{"action":` + "\n" + `{"action":{"id":"ban:1","type":"ban"}}` + "\n",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			path := filepath.Join(t.TempDir(), "outbox.json")
+			if err := os.WriteFile(path, []byte(tt.data), 0o600); err != nil {
+				t.Fatalf("WriteFile() error = %v", err)
+			}
+
+			o, err := New(path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("New() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			defer o.Close() //nolint:errcheck
+
+			var got []string
+			for _, a := range o.Due(time.Now()) {
+				got = append(got, a.ID)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("Due() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
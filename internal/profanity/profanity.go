@@ -0,0 +1,135 @@
+package profanity
+
+import (
+	"strings"
+	"unicode"
+)
+
+// DefaultRoots is a built-in list of Russian profanity and insult roots.
+// Roots ending with "*" match words starting with them, other roots match words with the same stem.
+var DefaultRoots = []string{
+	"хуй*", "хуе*", "хуя*", "хуи*",
+	"пизд*",
+	"еба*", "ебе*", "еби*", "ебл*", "ебн*", "ебу*",
+	"бля*",
+	"муда*", "мудил*", "мудо*",
+	"залуп*", "манда",
+	"пидор*", "пидар*", "пидр*", "гандон*",
+	"сука", "сучар*", "шлюх*", "шалав*",
+	"говн*", "дерьм*",
+	"дебил*", "идиот*", "кретин*", "дегенерат*", "ублюд*", "урод*", "мраз*", "тварь", "чмо", "лох",
+}
+
+// DefaultExceptions is a built-in list of regular words matched by DefaultRoots,
+// like "бляшка" matched by "бля*" and "уродился" matched by "урод*".
+var DefaultExceptions = []string{
+	"блях*", "бляш*",
+	"уродил*", "уродит*",
+}
+
+// prefixes are verb prefixes stripped before matching, like "за" in "заебал".
+// Single letter prefixes are stripped only with hard sign, so "себе" is not "ебе".
+var prefixes = []string{
+	"недо", "пере", "разъ", "подъ", "вы", "за", "на", "по", "до", "про", "при", "раз", "рас",
+	"из", "ис", "под", "от", "отъ", "об", "объ", "изъ", "у", "съ", "въ",
+}
+
+// Detector finds profane words.
+type Detector struct {
+	stems      map[string]struct{}
+	prefixes   []string
+	exceptions map[string]struct{}
+	exPrefixes []string
+}
+
+// New creates a new detector with roots and exceptions.
+// Exceptions have the same syntax as roots and exclude words like "страхуй" from matching.
+func New(roots, exceptions []string) *Detector {
+	d := &Detector{
+		stems:      make(map[string]struct{}),
+		exceptions: make(map[string]struct{}),
+	}
+	d.prefixes = compile(roots, d.stems)
+	d.exPrefixes = compile(exceptions, d.exceptions)
+
+	return d
+}
+
+// compile adds stems of roots to the set and returns prefix roots.
+func compile(roots []string, stems map[string]struct{}) []string {
+	var prefixRoots []string
+	for _, root := range roots {
+		root = fold(root)
+		if p, ok := strings.CutSuffix(root, "*"); ok {
+			prefixRoots = append(prefixRoots, p)
+			continue
+		}
+		stems[Stem(root)] = struct{}{}
+	}
+
+	return prefixRoots
+}
+
+// Find returns profane words among lowercase words.
+func (d *Detector) Find(words []string) []string {
+	var found []string
+	for _, word := range words {
+		if d.match(fold(word)) {
+			found = append(found, word)
+		}
+	}
+
+	return found
+}
+
+func (d *Detector) match(word string) bool {
+	if matchRoots(word, d.exceptions, d.exPrefixes) {
+		return false
+	}
+	if matchRoots(word, d.stems, d.prefixes) {
+		return true
+	}
+
+	for _, p := range prefixes {
+		if rest, ok := strings.CutPrefix(word, p); ok && rest != "" && matchRoots(rest, d.stems, d.prefixes) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func matchRoots(word string, stems map[string]struct{}, prefixRoots []string) bool {
+	for _, p := range prefixRoots {
+		if strings.HasPrefix(word, p) {
+			return true
+		}
+	}
+	_, ok := stems[Stem(word)]
+
+	return ok
+}
+
+// fold lowercases word and replaces "ё" with "е".
+func fold(word string) string {
+	return strings.ReplaceAll(strings.ToLower(word), "ё", "е")
+}
+
+// MixedScriptWords returns number of words mixing Cyrillic and Latin letters, like "хyй" with Latin "y".
+func MixedScriptWords(text string) int {
+	var count int
+	for _, word := range strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		var cyrillic, latin bool
+		for _, r := range word {
+			cyrillic = cyrillic || unicode.Is(unicode.Cyrillic, r)
+			latin = latin || unicode.Is(unicode.Latin, r)
+		}
+		if cyrillic && latin {
+			count++
+		}
+	}
+
+	return count
+}
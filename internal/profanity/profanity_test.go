@@ -0,0 +1,99 @@
+package profanity
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestStem(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		word string
+		want string
+	}{
+		{word: "сука", want: "сук"},
+		{word: "суки", want: "сук"},
+		{word: "сукой", want: "сук"},
+		{word: "сукно", want: "сукн"},
+		{word: "красивейший", want: "красив"},
+		{word: "бегущий", want: "бегущ"},
+		{word: "длинный", want: "длин"},
+		{word: "отъебись", want: "отъеб"},
+		{word: "ёлка", want: "елк"},
+		{word: "hello", want: "hello"},
+	}
+	for _, tt := range tests {
+		if got := Stem(tt.word); got != tt.want {
+			t.Errorf("Stem(%q) = %q, want %q", tt.word, got, tt.want)
+		}
+	}
+}
+
+func TestDetector_Find(t *testing.T) {
+	t.Parallel()
+
+	d := New(DefaultRoots, append([]string{"идиотизм"}, DefaultExceptions...))
+
+	tests := []struct {
+		name  string
+		words []string
+		want  []string
+	}{
+		{
+			name:  "inflected words",
+			words: []string{"ах", "ты", "сукой", "и", "дебилами"},
+			want:  []string{"сукой", "дебилами"},
+		},
+		{
+			name:  "prefixed words",
+			words: []string{"заебал", "отъебись", "распиздяй", "нахуй"},
+			want:  []string{"заебал", "отъебись", "распиздяй", "нахуй"},
+		},
+		{
+			name:  "similar regular words",
+			words: []string{"сукно", "себе", "застрахуйте", "мандарин", "рубля", "наблюдать", "хулиган"},
+			want:  nil,
+		},
+		{
+			name:  "exceptions",
+			words: []string{"идиотизм", "идиот"},
+			want:  []string{"идиот"},
+		},
+		{
+			name:  "default exceptions",
+			words: []string{"бляха", "бляхой", "бляшка", "бляшки", "уродился", "уродилась", "уродиться", "уродится"},
+			want:  nil,
+		},
+		{
+			name:  "roots of default exceptions",
+			words: []string{"бля", "блядь", "урод", "уроды", "уродина"},
+			want:  []string{"бля", "блядь", "урод", "уроды", "уродина"},
+		},
+		{
+			name:  "yo is folded",
+			words: []string{"ёбаный"},
+			want:  []string{"ёбаный"},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := d.Find(tt.words); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Find() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMixedScriptWords(t *testing.T) {
+	t.Parallel()
+
+	// "хyй" and "cука" contain Latin "y" and "c".
+	if got := MixedScriptWords("хyй тебе, cука, not сука"); got != 2 {
+		t.Errorf("MixedScriptWords() = %v, want %v", got, 2)
+	}
+}
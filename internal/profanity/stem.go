@@ -0,0 +1,136 @@
+package profanity
+
+import "strings"
+
+// Endings of the Snowball Russian stemmer.
+// Endings of the first groups are removed only after "а" or "я".
+var (
+	perfectiveGerund1 = []string{"в", "вши", "вшись"}
+	perfectiveGerund2 = []string{"ив", "ивши", "ившись", "ыв", "ывши", "ывшись"}
+	adjective         = []string{
+		"ее", "ие", "ые", "ое", "ими", "ыми", "ей", "ий", "ый", "ой", "ем", "им", "ым", "ом",
+		"его", "ого", "ему", "ому", "их", "ых", "ую", "юю", "ая", "яя", "ою", "ею",
+	}
+	participle1 = []string{"ем", "нн", "вш", "ющ", "щ"}
+	participle2 = []string{"ивш", "ывш", "ующ"}
+	reflexive   = []string{"ся", "сь"}
+	verb1       = []string{"ла", "на", "ете", "йте", "ли", "й", "л", "ем", "н", "ло", "но", "ет", "ют", "ны", "ть", "ешь", "нно"}
+	verb2       = []string{
+		"ила", "ыла", "ена", "ейте", "уйте", "ите", "или", "ыли", "ей", "уй", "ил", "ыл", "им", "ым", "ен",
+		"ило", "ыло", "ено", "ят", "ует", "уют", "ит", "ыт", "ены", "ить", "ыть", "ишь", "ую", "ю",
+	}
+	noun = []string{
+		"а", "ев", "ов", "ие", "ье", "е", "иями", "ями", "ами", "еи", "ии", "и", "ией", "ей", "ой", "ий", "й",
+		"иям", "ям", "ием", "ем", "ам", "ом", "о", "у", "ах", "иях", "ях", "ы", "ь", "ию", "ью", "ю", "ия", "ья", "я",
+	}
+	derivational = []string{"ост", "ость"}
+	superlative  = []string{"ейш", "ейше"}
+)
+
+// Stem returns stem of lowercase Russian word with the Snowball algorithm.
+// Words in other scripts are returned as is.
+func Stem(word string) string {
+	w := []rune(strings.ReplaceAll(word, "ё", "е"))
+	rv, r2 := regions(w)
+	if rv >= len(w) {
+		return string(w)
+	}
+
+	// Step 1.
+	var ok bool
+	if w, ok = removeEnding(w, rv, perfectiveGerund1, true); !ok {
+		if w, ok = removeEnding(w, rv, perfectiveGerund2, false); !ok {
+			w, _ = removeEnding(w, rv, reflexive, false)
+			if w, ok = removeEnding(w, rv, adjective, false); ok {
+				if w, ok = removeEnding(w, rv, participle1, true); !ok {
+					w, _ = removeEnding(w, rv, participle2, false)
+				}
+			} else if w, ok = removeEnding(w, rv, verb1, true); !ok {
+				if w, ok = removeEnding(w, rv, verb2, false); !ok {
+					w, _ = removeEnding(w, rv, noun, false)
+				}
+			}
+		}
+	}
+
+	// Step 2.
+	w, _ = removeEnding(w, rv, []string{"и"}, false)
+
+	// Step 3.
+	w, _ = removeEnding(w, r2, derivational, false)
+
+	// Step 4.
+	if w, ok = removeEnding(w, rv, superlative, false); ok || hasSuffix(w, rv, "нн") {
+		if hasSuffix(w, rv, "нн") {
+			w = w[:len(w)-1]
+		}
+	} else if hasSuffix(w, rv, "ь") {
+		w = w[:len(w)-1]
+	}
+
+	return string(w)
+}
+
+// regions returns start of RV region, which is after the first vowel,
+// and start of R2 region, which is after the second vowel followed by a non-vowel.
+func regions(w []rune) (rv, r2 int) {
+	rv, r1, r2 := len(w), len(w), len(w)
+	for i, r := range w {
+		if isVowel(r) {
+			rv = i + 1
+			break
+		}
+	}
+	for i := 1; i < len(w); i++ {
+		if !isVowel(w[i]) && isVowel(w[i-1]) {
+			r1 = i + 1
+			break
+		}
+	}
+	for i := r1 + 1; i < len(w); i++ {
+		if !isVowel(w[i]) && isVowel(w[i-1]) {
+			r2 = i + 1
+			break
+		}
+	}
+
+	return rv, r2
+}
+
+func isVowel(r rune) bool {
+	return strings.ContainsRune("аеиоуыэюя", r)
+}
+
+// removeEnding removes the longest of endings which is inside the region.
+// Endings of the first group are removed only after "а" or "я" which is kept.
+func removeEnding(w []rune, region int, endings []string, group1 bool) ([]rune, bool) {
+	longest := -1
+	for _, e := range endings {
+		n := len([]rune(e))
+		if n <= longest || !hasSuffix(w, region, e) {
+			continue
+		}
+		if group1 {
+			i := len(w) - n - 1
+			if i < region || (w[i] != 'а' && w[i] != 'я') {
+				continue
+			}
+		}
+		longest = n
+	}
+	if longest < 0 {
+		return w, false
+	}
+
+	return w[:len(w)-longest], true
+}
+
+// hasSuffix reports whether word ends with suffix inside the region.
+func hasSuffix(w []rune, region int, suffix string) bool {
+	s := []rune(suffix)
+	if len(w)-len(s) < region {
+		return false
+	}
+
+	return string(w[len(w)-len(s):]) == suffix
+}
@@ -2,17 +2,31 @@ package server
 
 import (
 	"context"
+	"crypto/subtle"
 	"encoding/json"
+	"expvar"
+	"net"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/sklyar/vk-banhammer/internal/entity"
 	"go.uber.org/zap"
 )
 
+// shutdownTimeout is a time given to requests in flight to finish on shutdown.
+const shutdownTimeout = 10 * time.Second
+
 type service interface {
 	// CheckComment checks comment and ban user if needed.
-	CheckComment(comment *entity.Comment) (entity.BanReason, error)
+	CheckComment(ctx context.Context, comment *entity.Comment) (entity.Decision, error)
+	// ExplainComment evaluates rules for comment and returns the decision without punishing.
+	ExplainComment(ctx context.Context, comment *entity.Comment) (entity.Decision, error)
+	// InvalidateUser removes user from the cache.
+	InvalidateUser(userID int)
+	// InvalidateCache removes all users from the cache.
+	InvalidateCache()
 }
 
 // Server is a banhammer HTTP server.
@@ -25,11 +39,15 @@ type Server struct {
 	// It is used to confirm that the server is the one that should receive the callback.
 	callbackConfirmationCode string
 
+	// adminToken is a bearer token for admin endpoints.
+	// Admin endpoints are disabled if it is empty.
+	adminToken string
+
 	logger *zap.Logger
 }
 
 // NewServer creates a new banhammer HTTP server.
-func NewServer(logger *zap.Logger, addr string, service service, callbackConfirmationCode string) *Server {
+func NewServer(logger *zap.Logger, addr string, service service, callbackConfirmationCode, adminToken string) *Server {
 	mux := http.NewServeMux()
 
 	srv := &Server{
@@ -42,10 +60,16 @@ func NewServer(logger *zap.Logger, addr string, service service, callbackConfirm
 		},
 		service:                  service,
 		callbackConfirmationCode: callbackConfirmationCode,
+		adminToken:               adminToken,
 		logger:                   logger,
 	}
 
 	mux.HandleFunc("/new_message", srv.gatewayHandler)
+	if adminToken != "" {
+		mux.HandleFunc("/admin/cache/invalidate", srv.adminAuth(srv.invalidateCacheHandler))
+		mux.HandleFunc("/admin/check", srv.adminAuth(srv.checkCommentHandler))
+		mux.HandleFunc("/admin/metrics", srv.adminAuth(expvar.Handler().ServeHTTP))
+	}
 
 	return srv
 }
@@ -89,27 +113,99 @@ func (s *Server) wallReplyNewHandler(w http.ResponseWriter, r *request) {
 		return
 	}
 
-	reason, err := s.service.CheckComment(&comment)
-	if err != nil {
+	// Decision is logged by the service.
+	if _, err := s.service.CheckComment(r.HTTPRequest.Context(), &comment); err != nil {
 		s.logger.Error("failed to check comment", zap.Error(err))
+	}
+	_, _ = w.Write([]byte("ok"))
+}
+
+func (s *Server) unknownTypeHandler(w http.ResponseWriter, msgRequest *request) {
+	s.logger.Error("unknown type", zap.String("msg_type", msgRequest.Type))
+	_, _ = w.Write([]byte("ok"))
+}
+
+func (s *Server) adminAuth(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(token), []byte(s.adminToken)) != 1 {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// invalidateCacheHandler removes user with the given user_id from the cache.
+// If user_id is not set, the whole cache is invalidated.
+func (s *Server) invalidateCacheHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	rawUserID := r.URL.Query().Get("user_id")
+	if rawUserID == "" {
+		s.service.InvalidateCache()
+		s.logger.Info("user cache invalidated")
 		_, _ = w.Write([]byte("ok"))
 		return
 	}
 
-	if reason == entity.BanReasonPersonNonGrata {
-		s.logger.Info("banning user", zap.Reflect("comment", comment))
+	userID, err := strconv.Atoi(rawUserID)
+	if err != nil {
+		http.Error(w, "invalid user_id", http.StatusBadRequest)
+		return
 	}
+
+	s.service.InvalidateUser(userID)
+	s.logger.Info("user invalidated in cache", zap.Int("user_id", userID))
 	_, _ = w.Write([]byte("ok"))
 }
 
-func (s *Server) unknownTypeHandler(w http.ResponseWriter, msgRequest *request) {
-	s.logger.Error("unknown type", zap.String("msg_type", msgRequest.Type))
-	_, _ = w.Write([]byte("ok"))
+// checkResponse is a response of the admin check endpoint.
+type checkResponse struct {
+	Decision entity.Decision `json:"decision"`
+	Error    string          `json:"error,omitempty"`
+}
+
+// checkCommentHandler checks comment from the request body, like it came from VK callback API,
+// and returns the decision. It is a dry run, nothing is punished or recorded.
+func (s *Server) checkCommentHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var comment entity.Comment
+	if err := json.NewDecoder(r.Body).Decode(&comment); err != nil {
+		http.Error(w, "invalid comment", http.StatusBadRequest)
+		return
+	}
+
+	decision, err := s.service.ExplainComment(r.Context(), &comment)
+	res := checkResponse{Decision: decision}
+	status := http.StatusOK
+	if err != nil {
+		res.Error = err.Error()
+		status = http.StatusInternalServerError
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(res); err != nil {
+		s.logger.Error("failed to write decision", zap.Error(err))
+	}
 }
 
 // ListenAndServe starts HTTP server.
 // It blocks until the context is canceled.
+// Requests in flight are canceled together with the context.
 func (s *Server) ListenAndServe(ctx context.Context) error {
+	s.srv.BaseContext = func(net.Listener) context.Context {
+		return ctx
+	}
+
 	errCh := make(chan error, 1)
 	go func() {
 		errCh <- s.srv.ListenAndServe()
@@ -119,7 +215,10 @@ func (s *Server) ListenAndServe(ctx context.Context) error {
 
 	select {
 	case <-ctx.Done():
-		return s.srv.Shutdown(ctx)
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+
+		return s.srv.Shutdown(shutdownCtx)
 	case err := <-errCh:
 		return err
 	}
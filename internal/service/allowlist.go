@@ -0,0 +1,137 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/SevereCloud/vksdk/v2/api"
+	"go.uber.org/zap"
+)
+
+// membersPageSize is a maximum number of members returned by groups.getMembers.
+const membersPageSize = 1000
+
+// Allowlist keeps trusted users which are never banned.
+type Allowlist interface {
+	// Contains checks if user is trusted at the given time.
+	Contains(userID int, now time.Time) bool
+	// TracksMembers reports whether community members should be refreshed.
+	TracksMembers() bool
+	// SetTrusted replaces explicitly trusted users.
+	SetTrusted(ids []int)
+	// SetManagers replaces community managers.
+	SetManagers(ids []int)
+	// SetMembers replaces community members.
+	SetMembers(ids []int, now time.Time) error
+}
+
+// RunAllowlistRefresher periodically refreshes community managers and members
+// in the allowlist until the context is canceled.
+// It does nothing if the community is not configured.
+//
+// Every refresh pages through all members, which takes one groups.getMembers call
+// per membersPageSize members, so large communities need a longer refresh interval.
+// The members file is rewritten only if membership has changed.
+func (s *Service) RunAllowlistRefresher(ctx context.Context) {
+	if s.groupID == 0 {
+		return
+	}
+
+	ticker := time.NewTicker(s.allowlistRefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.refreshAllowlist(ctx); err != nil {
+			s.logger.Error("failed to refresh allowlist", zap.Error(err), zap.String("error_class", errorClass(err)))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Service) refreshAllowlist(ctx context.Context) error {
+	managers, err := s.getManagers(ctx)
+	if err != nil {
+		return err
+	}
+	s.allowlist.SetManagers(managers)
+
+	if !s.allowlist.TracksMembers() {
+		return nil
+	}
+
+	members, err := s.getMembers(ctx)
+	if err != nil {
+		return err
+	}
+
+	return s.allowlist.SetMembers(members, time.Now())
+}
+
+func (s *Service) getManagers(ctx context.Context) ([]int, error) {
+	var ids []int
+	for offset := 0; ; offset += membersPageSize {
+		res, err := s.getManagersPage(ctx, offset)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, u := range res.Items {
+			ids = append(ids, u.ID)
+		}
+		if len(res.Items) == 0 || offset+membersPageSize >= res.Count {
+			return ids, nil
+		}
+	}
+}
+
+func (s *Service) getManagersPage(ctx context.Context, offset int) (api.GroupsGetMembersFilterManagersResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.groupsGetMembersTimeout)
+	defer cancel()
+
+	res, err := s.client.GroupsGetMembersFilterManagers(ctx, api.Params{
+		"group_id": s.groupID,
+		"offset":   offset,
+		"count":    membersPageSize,
+	})
+	if err != nil {
+		return api.GroupsGetMembersFilterManagersResponse{}, classifyError(methodGroupsGetMembers, err)
+	}
+
+	return res, nil
+}
+
+func (s *Service) getMembers(ctx context.Context) ([]int, error) {
+	var ids []int
+	for offset := 0; ; offset += membersPageSize {
+		res, err := s.getMembersPage(ctx, offset)
+		if err != nil {
+			return nil, err
+		}
+
+		ids = append(ids, res.Items...)
+		if len(res.Items) == 0 || offset+membersPageSize >= res.Count {
+			return ids, nil
+		}
+	}
+}
+
+func (s *Service) getMembersPage(ctx context.Context, offset int) (api.GroupsGetMembersResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.groupsGetMembersTimeout)
+	defer cancel()
+
+	res, err := s.client.GroupsGetMembers(ctx, api.Params{
+		"group_id": s.groupID,
+		"offset":   offset,
+		"count":    membersPageSize,
+	})
+	if err != nil {
+		return api.GroupsGetMembersResponse{}, classifyError(methodGroupsGetMembers, err)
+	}
+
+	return res, nil
+}
@@ -0,0 +1,116 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/SevereCloud/vksdk/v2/api"
+	"github.com/SevereCloud/vksdk/v2/object"
+	"github.com/sklyar/vk-banhammer/internal/entity"
+	"go.uber.org/zap"
+)
+
+// communitySkipReason returns why comment authored by community is never checked.
+// It returns empty string for other comments.
+func communitySkipReason(rules *ruleSet, comment *entity.Comment) string {
+	switch {
+	case comment.FromID >= 0:
+		return ""
+	case comment.FromID == comment.OwnerID:
+		// Community answers on its own wall.
+		return skippedOwnWall
+	case rules.heuristics.IsExemptCommunity(-comment.FromID):
+		return skippedExemptCommunity
+	default:
+		return ""
+	}
+}
+
+// checkCommunityComment checks comment authored by community and ban the community if needed.
+func (s *Service) checkCommunityComment(
+	ctx context.Context,
+	rules *ruleSet,
+	comment *entity.Comment,
+	history entity.CommentHistory,
+	d *entity.Decision,
+) error {
+	group, err := s.getGroupByID(ctx, -comment.FromID)
+	if err != nil {
+		// Deactivated communities can not comment anymore, nothing to do.
+		if errors.Is(err, ErrCommunityDeactivated) {
+			d.Skipped = skippedDeactivated
+			return nil
+		}
+		s.logger.Error(
+			"failed to get community",
+			zap.Error(err),
+			zap.String("error_class", errorClass(err)),
+			zap.Reflect("comment", comment),
+		)
+		return fmt.Errorf("failed to get community: %w", err)
+	}
+
+	s.logger.Debug(
+		"new community comment",
+		zap.Int("id", group.ID),
+		zap.String("name", group.Name),
+		zap.String("screen_name", group.ScreenName),
+		zap.String("text", comment.Text),
+		zap.String("normalized_text", comment.NormalizedText()),
+	)
+
+	match, matched := rules.heuristics.CheckCommunity(group, d)
+	if !matched {
+		match, matched = rules.heuristics.CheckComment(comment, d)
+	}
+	if !matched {
+		match, matched = rules.heuristics.CheckExpressions(nil, comment, history, d)
+	}
+	if !matched {
+		match, matched = s.checkLinks(ctx, rules, comment, d)
+	}
+	if !matched {
+		return nil
+	}
+
+	// Communities are banned by negative owner id.
+	return s.punish(ctx, d, comment, comment.FromID, match)
+}
+
+func (s *Service) getGroupByID(ctx context.Context, groupID int) (*object.GroupsGroup, error) {
+	g, exists := s.groupCache.Get(groupID)
+	if exists {
+		return g, nil
+	}
+	if err, exists := s.negativeCache.Get(-groupID); exists {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.groupsGetByIDTimeout)
+	defer cancel()
+
+	groups, err := s.client.GroupsGetByID(
+		ctx,
+		api.Params{
+			"group_ids": groupID,
+			"fields":    "verified,members_count",
+		},
+	)
+	if err != nil {
+		return nil, classifyError(methodGroupsGetByID, err)
+	}
+	if len(groups) == 0 {
+		s.negativeCache.Add(-groupID, ErrCommunityNotFound)
+		return nil, ErrCommunityNotFound
+	}
+
+	g = &groups[0]
+	if g.Deactivated != "" {
+		s.negativeCache.Add(-groupID, ErrCommunityDeactivated)
+		return nil, ErrCommunityDeactivated
+	}
+	s.groupCache.Add(groupID, g)
+
+	return g, nil
+}
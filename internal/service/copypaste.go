@@ -0,0 +1,84 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/sklyar/vk-banhammer/internal/copypaste"
+	"github.com/sklyar/vk-banhammer/internal/entity"
+	"go.uber.org/zap"
+)
+
+// checkCopyPaste records comment fingerprint and checks copy-paste rules.
+// Earlier copies are deleted when the matched rule asks for cleanup.
+// Dry run neither records the fingerprint nor deletes copies.
+func (s *Service) checkCopyPaste(
+	ctx context.Context,
+	rules *ruleSet,
+	comment *entity.Comment,
+	now time.Time,
+	d *entity.Decision,
+) (entity.Match, bool) {
+	if len(rules.heuristics.CopyPaste) == 0 {
+		return entity.Match{}, false
+	}
+
+	hash, words := copypaste.Fingerprint(comment.Text)
+	entry := copypaste.Entry{
+		Hash:      hash,
+		FromID:    comment.FromID,
+		OwnerID:   comment.OwnerID,
+		CommentID: comment.ID,
+		At:        now,
+	}
+
+	var (
+		match   entity.Match
+		matched bool
+	)
+	// Comment is recorded by every rule, even if an earlier rule matched.
+	for i, r := range rules.heuristics.CopyPaste {
+		if words < r.Words() {
+			continue
+		}
+
+		var (
+			copies []copypaste.Entry
+			ok     bool
+		)
+		if d.DryRun {
+			copies, ok = rules.copyPaste[i].Check(entry)
+		} else {
+			copies, ok = rules.copyPaste[i].Add(entry)
+		}
+		d.AddRule("copy_paste", i, ok, 0, map[string]any{"words": words, "copies": len(copies)})
+		if !ok {
+			continue
+		}
+		if r.Cleanup && !d.DryRun {
+			s.cleanupCopies(ctx, d, copies, now)
+		}
+		if !matched {
+			match, matched = r.Match(), true
+		}
+	}
+
+	return match, matched
+}
+
+// cleanupCopies deletes earlier copies of copy-pasted comment.
+// Failed deletions are retried by the outbox worker.
+func (s *Service) cleanupCopies(ctx context.Context, d *entity.Decision, copies []copypaste.Entry, now time.Time) {
+	if len(copies) == 0 {
+		return
+	}
+
+	actions := make([]entity.Action, 0, len(copies))
+	for _, c := range copies {
+		actions = append(actions, entity.NewDeleteCommentAction(c.OwnerID, c.CommentID, now))
+	}
+
+	if err := s.enqueue(ctx, d, actions, now); err != nil {
+		s.logger.Error("failed to delete copies", zap.Error(err), zap.Int("count", len(copies)))
+	}
+}
@@ -0,0 +1,168 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"fmt"
+	"strings"
+
+	"github.com/SevereCloud/vksdk/v2/api"
+)
+
+// VK API methods used by the service.
+const (
+	methodUsersGet               = "users.get"
+	methodGroupsGetByID          = "groups.getById"
+	methodGroupsGetMembers       = "groups.getMembers"
+	methodUtilsResolveScreenName = "utils.resolveScreenName"
+	methodGroupsBan              = "groups.ban"
+	methodWallDeleteComment      = "wall.deleteComment"
+	methodUtilsCheckLink         = "utils.checkLink"
+)
+
+// ErrorClass is a class of failed VK API call.
+// Errors returned from VK API calls match their class with errors.Is.
+type ErrorClass string
+
+func (c ErrorClass) Error() string {
+	return strings.ReplaceAll(string(c), "_", " ")
+}
+
+// Available error classes.
+const (
+	// ErrAlreadyBanned is returned when user is already in the group blacklist.
+	ErrAlreadyBanned ErrorClass = "already_banned"
+	// ErrCommentAlreadyDeleted is returned when comment is already deleted.
+	ErrCommentAlreadyDeleted ErrorClass = "comment_already_deleted"
+	// ErrAccessDenied is returned when token has no rights for the call.
+	ErrAccessDenied ErrorClass = "access_denied"
+	// ErrCaptchaNeeded is returned when VK asks to solve captcha.
+	ErrCaptchaNeeded ErrorClass = "captcha_needed"
+	// ErrRateLimited is returned when calls are too frequent.
+	ErrRateLimited ErrorClass = "rate_limited"
+	// ErrVKUnavailable is returned when VK fails internally.
+	ErrVKUnavailable ErrorClass = "vk_unavailable"
+	// ErrInvalidRequest is returned for any other VK API error.
+	ErrInvalidRequest ErrorClass = "invalid_request"
+)
+
+// apiErrors counts failed VK API calls by method and error class.
+var apiErrors = expvar.NewMap("vk_api_errors")
+
+// APIError is a failed VK API call.
+type APIError struct {
+	Method string
+	Class  ErrorClass
+	Err    error
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("%s: %s: %v", e.Method, e.Class, e.Err)
+}
+
+func (e *APIError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether target is the class of the error.
+func (e *APIError) Is(target error) bool {
+	class, ok := target.(ErrorClass)
+	return ok && class == e.Class
+}
+
+// classifyError wraps error returned by VK API method into APIError.
+// Transport errors are returned as is.
+func classifyError(method string, err error) error {
+	var vkErr *api.Error
+	if !errors.As(err, &vkErr) {
+		return err
+	}
+
+	classified := &APIError{Method: method, Class: apiErrorClass(method, vkErr), Err: err}
+	apiErrors.Add(method+":"+string(classified.Class), 1)
+
+	return classified
+}
+
+func apiErrorClass(method string, err *api.Error) ErrorClass {
+	switch err.Code {
+	case api.ErrTooMany, api.ErrFlood, api.ErrRateLimit:
+		return ErrRateLimited
+	case api.ErrUnknown, api.ErrServer, api.ErrExecutionTimeout:
+		return ErrVKUnavailable
+	case api.ErrCaptcha:
+		return ErrCaptchaNeeded
+	case api.ErrAuth, api.ErrPermission, api.ErrAccess, api.ErrMethodPermission, api.ErrGroupAuth, api.ErrAccessGroup:
+		return ErrAccessDenied
+	}
+
+	switch method {
+	case methodGroupsBan:
+		// VK has no code for repeated ban, it is reported as invalid parameter.
+		if err.Code == api.ErrParam && isAlreadyBannedMessage(err.Message) {
+			return ErrAlreadyBanned
+		}
+	case methodWallDeleteComment:
+		switch err.Code {
+		case api.ErrNotFound:
+			return ErrCommentAlreadyDeleted
+		case api.ErrWallAccessComment:
+			// Comment may exist, the token has no rights to delete it.
+			return ErrAccessDenied
+		}
+	}
+
+	return ErrInvalidRequest
+}
+
+// alreadyBannedMessages contains parts of groups.ban error messages for users already in the blacklist.
+var alreadyBannedMessages = []string{"already banned", "already in ban list", "already in blacklist"}
+
+func isAlreadyBannedMessage(msg string) bool {
+	msg = strings.ToLower(msg)
+	for _, m := range alreadyBannedMessages {
+		if strings.Contains(msg, m) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// errorClass returns class of the error for logs.
+func errorClass(err error) string {
+	var apiErr *APIError
+	switch {
+	case errors.As(err, &apiErr):
+		return string(apiErr.Class)
+	case errors.Is(err, ErrBadResponse):
+		return "bad_response"
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	default:
+		return "transport"
+	}
+}
+
+// isIdempotentSuccess reports whether failed action is already done.
+func isIdempotentSuccess(err error) bool {
+	return errors.Is(err, ErrAlreadyBanned) || errors.Is(err, ErrCommentAlreadyDeleted)
+}
+
+// isRetryable reports whether failed action may succeed later.
+func isRetryable(err error) bool {
+	if errors.Is(err, errUnknownAction) || errors.Is(err, ErrBadResponse) {
+		return false
+	}
+
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Class == ErrRateLimited || apiErr.Class == ErrVKUnavailable
+	}
+
+	// Network errors and timeouts.
+	return true
+}
@@ -0,0 +1,159 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/SevereCloud/vksdk/v2/api"
+)
+
+func TestClassifyError(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name          string
+		method        string
+		err           error
+		want          error
+		wantRetryable bool
+	}{
+		{
+			name:          "rate limited",
+			method:        methodGroupsBan,
+			err:           &api.Error{Code: api.ErrTooMany},
+			want:          ErrRateLimited,
+			wantRetryable: true,
+		},
+		{
+			name:          "vk unavailable",
+			method:        methodUsersGet,
+			err:           &api.Error{Code: api.ErrServer},
+			want:          ErrVKUnavailable,
+			wantRetryable: true,
+		},
+		{
+			name:          "captcha needed",
+			method:        methodGroupsBan,
+			err:           &api.Error{Code: api.ErrCaptcha},
+			want:          ErrCaptchaNeeded,
+			wantRetryable: false,
+		},
+		{
+			name:          "access denied",
+			method:        methodWallDeleteComment,
+			err:           &api.Error{Code: api.ErrPermission},
+			want:          ErrAccessDenied,
+			wantRetryable: false,
+		},
+		{
+			name:          "already banned",
+			method:        methodGroupsBan,
+			err:           &api.Error{Code: api.ErrParam, Message: "One of the parameters specified was missing or invalid: user already banned"},
+			want:          ErrAlreadyBanned,
+			wantRetryable: false,
+		},
+		{
+			name:          "comment already deleted",
+			method:        methodWallDeleteComment,
+			err:           &api.Error{Code: api.ErrNotFound},
+			want:          ErrCommentAlreadyDeleted,
+			wantRetryable: false,
+		},
+		{
+			name:          "access to comment denied",
+			method:        methodWallDeleteComment,
+			err:           &api.Error{Code: api.ErrWallAccessComment, Message: "Access to wall's comment denied"},
+			want:          ErrAccessDenied,
+			wantRetryable: false,
+		},
+		{
+			name:          "other invalid parameter of ban",
+			method:        methodGroupsBan,
+			err:           &api.Error{Code: api.ErrParam, Message: "One of the parameters specified was missing or invalid: already expired end_date"},
+			want:          ErrInvalidRequest,
+			wantRetryable: false,
+		},
+		{
+			name:          "invalid request",
+			method:        methodGroupsBan,
+			err:           &api.Error{Code: api.ErrParam},
+			want:          ErrInvalidRequest,
+			wantRetryable: false,
+		},
+		{
+			name:          "transport error",
+			method:        methodGroupsBan,
+			err:           context.DeadlineExceeded,
+			want:          context.DeadlineExceeded,
+			wantRetryable: true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := classifyError(tt.method, tt.err)
+			if !errors.Is(got, tt.want) {
+				t.Errorf("classifyError() = %v, want %v", got, tt.want)
+			}
+			if !errors.Is(got, tt.err) {
+				t.Errorf("classifyError() = %v, does not wrap %v", got, tt.err)
+			}
+			if isRetryable(got) != tt.wantRetryable {
+				t.Errorf("isRetryable() = %v, want %v", isRetryable(got), tt.wantRetryable)
+			}
+		})
+	}
+}
+
+func TestClassifyError_VKResponse(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		method string
+		body   string
+		want   error
+	}{
+		{
+			name:   "already banned",
+			method: methodGroupsBan,
+			body:   `{"error":{"error_code":100,"error_msg":"One of the parameters specified was missing or invalid: user already in ban list"}}`,
+			want:   ErrAlreadyBanned,
+		},
+		{
+			name:   "comment not found",
+			method: methodWallDeleteComment,
+			body:   `{"error":{"error_code":104,"error_msg":"Not found"}}`,
+			want:   ErrCommentAlreadyDeleted,
+		},
+		{
+			name:   "access to comment denied",
+			method: methodWallDeleteComment,
+			body:   `{"error":{"error_code":211,"error_msg":"Access to wall's comment denied"}}`,
+			want:   ErrAccessDenied,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			// Errors are decoded the same way as vksdk does.
+			var res api.Response
+			if err := json.Unmarshal([]byte(tt.body), &res); err != nil {
+				t.Fatalf("Unmarshal() error = %v", err)
+			}
+
+			got := classifyError(tt.method, &res.Error)
+			if !errors.Is(got, tt.want) {
+				t.Errorf("classifyError() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// foafURL is an URL of public user profiles in FOAF format.
+const foafURL = "https://vk.com/foaf.php"
+
+// foafCreatedRe matches registration date in FOAF profile.
+var foafCreatedRe = regexp.MustCompile(`<ya:created dc:date="([^"]+)"`)
+
+// foafClient is a RegistrationDates reading public FOAF profiles,
+// registration date is not available in VK API.
+type foafClient struct {
+	http *http.Client
+}
+
+// NewFoafClient creates a new client of public FOAF profiles.
+// Zero timeout is replaced with default.
+func NewFoafClient(timeout time.Duration) RegistrationDates {
+	if timeout <= 0 {
+		timeout = defaultAPITimeout
+	}
+
+	return &foafClient{http: &http.Client{Timeout: timeout}}
+}
+
+func (c *foafClient) RegistrationDate(ctx context.Context, userID int) (time.Time, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, foafURL+"?id="+strconv.Itoa(userID), nil)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to get foaf: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return time.Time{}, fmt.Errorf("%w: foaf status %d", ErrBadResponse, resp.StatusCode)
+	}
+
+	// Profile is small, the limit protects from unexpected responses.
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to read foaf: %w", err)
+	}
+
+	return parseRegistrationDate(body)
+}
+
+// parseRegistrationDate returns registration date from FOAF profile.
+func parseRegistrationDate(foaf []byte) (time.Time, error) {
+	m := foafCreatedRe.FindSubmatch(foaf)
+	if m == nil {
+		return time.Time{}, fmt.Errorf("%w: no registration date in foaf", ErrBadResponse)
+	}
+
+	created, err := time.Parse(time.RFC3339, string(m[1]))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: invalid registration date in foaf: %v", ErrBadResponse, err)
+	}
+
+	return created, nil
+}
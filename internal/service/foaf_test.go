@@ -0,0 +1,53 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseRegistrationDate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		foaf    string
+		want    time.Time
+		wantErr bool
+	}{
+		{
+			name: "registration date",
+			foaf: `<foaf:Person><ya:created dc:date="2006-09-23T20:28:46+03:00"/></foaf:Person>`,
+			want: time.Date(2006, 9, 23, 17, 28, 46, 0, time.UTC),
+		},
+		{
+			name:    "no registration date",
+			foaf:    `<foaf:Person></foaf:Person>`,
+			wantErr: true,
+		},
+		{
+			name:    "invalid registration date",
+			foaf:    `<ya:created dc:date="yesterday"/>`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := parseRegistrationDate([]byte(tt.foaf))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseRegistrationDate() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err != nil && !errors.Is(err, ErrBadResponse) {
+				t.Errorf("parseRegistrationDate() error = %v, want %v", err, ErrBadResponse)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("parseRegistrationDate() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
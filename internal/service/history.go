@@ -0,0 +1,38 @@
+package service
+
+import (
+	"github.com/sklyar/vk-banhammer/internal/entity"
+)
+
+// recordComment counts comment in the history of its author and returns history before it.
+func (s *Service) recordComment(fromID int) entity.CommentHistory {
+	s.historyM.Lock()
+	defer s.historyM.Unlock()
+
+	h, _ := s.history.Get(fromID)
+	next := h
+	next.Comments++
+	s.history.Add(fromID, next)
+
+	return h
+}
+
+// commentHistory returns comment history of the author without recording a comment.
+func (s *Service) commentHistory(fromID int) entity.CommentHistory {
+	s.historyM.Lock()
+	defer s.historyM.Unlock()
+
+	h, _ := s.history.Peek(fromID)
+
+	return h
+}
+
+// recordPunished counts punished comment in the history of its author.
+func (s *Service) recordPunished(fromID int) {
+	s.historyM.Lock()
+	defer s.historyM.Unlock()
+
+	h, _ := s.history.Get(fromID)
+	h.Punished++
+	s.history.Add(fromID, h)
+}
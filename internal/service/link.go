@@ -0,0 +1,111 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/SevereCloud/vksdk/v2/api"
+	"github.com/sklyar/vk-banhammer/internal/entity"
+	"go.uber.org/zap"
+)
+
+// checkLinks checks comment links with link rules, short links are resolved first.
+func (s *Service) checkLinks(
+	ctx context.Context,
+	rules *ruleSet,
+	comment *entity.Comment,
+	d *entity.Decision,
+) (entity.Match, bool) {
+	if len(rules.heuristics.Link) == 0 {
+		return entity.Match{}, false
+	}
+
+	links := comment.Links()
+	hosts := make([]string, 0, len(links))
+	for _, link := range links {
+		hosts = append(hosts, entity.LinkHost(s.resolveLink(ctx, rules, link)))
+	}
+
+	return rules.heuristics.CheckLinks(hosts, func() (time.Duration, error) {
+		registered, err := s.getRegistrationDate(ctx, comment.FromID)
+		if err != nil {
+			s.logger.Warn(
+				"failed to get registration date",
+				zap.Error(err),
+				zap.String("error_class", errorClass(err)),
+				zap.Int("id", comment.FromID),
+			)
+			return 0, err
+		}
+
+		return time.Since(registered), nil
+	}, d)
+}
+
+// resolveLink returns target of the short link.
+// Other links and links which failed to resolve are returned as is,
+// so the shortener domain itself is still checked.
+func (s *Service) resolveLink(ctx context.Context, rules *ruleSet, link string) string {
+	if !rules.heuristics.IsShortener(entity.LinkHost(link)) {
+		return link
+	}
+	if resolved, exists := s.linkCache.Get(link); exists {
+		return resolved
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.checkLinkTimeout)
+	defer cancel()
+
+	res, err := s.client.UtilsCheckLink(ctx, api.Params{"url": link})
+	if err != nil {
+		err = classifyError(methodUtilsCheckLink, err)
+		s.logger.Warn(
+			"failed to resolve link",
+			zap.Error(err),
+			zap.String("error_class", errorClass(err)),
+			zap.String("link", link),
+		)
+		return link
+	}
+	if res.Link == "" {
+		return link
+	}
+	s.linkCache.Add(link, res.Link)
+
+	return res.Link
+}
+
+// registration is a cached registration date lookup.
+// Failed lookups are cached with err, so FOAF is not asked on every comment while it is down.
+type registration struct {
+	date     time.Time
+	err      error
+	failedAt time.Time
+}
+
+func (s *Service) getRegistrationDate(ctx context.Context, userID int) (time.Time, error) {
+	// Registration date of communities is not known.
+	if userID < 0 || s.registrationDates == nil {
+		return time.Time{}, ErrRegistrationDateUnknown
+	}
+	if cached, exists := s.registrationCache.Get(userID); exists {
+		if cached.err == nil {
+			return cached.date, nil
+		}
+		if time.Since(cached.failedAt) < s.registrationFailureTTL {
+			return time.Time{}, cached.err
+		}
+	}
+
+	registered, err := s.registrationDates.RegistrationDate(ctx, userID)
+	if err != nil {
+		// Canceled lookup says nothing about the user, it is not cached.
+		if ctx.Err() == nil {
+			s.registrationCache.Add(userID, registration{err: err, failedAt: time.Now()})
+		}
+		return time.Time{}, err
+	}
+	s.registrationCache.Add(userID, registration{date: registered})
+
+	return registered, nil
+}
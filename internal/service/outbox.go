@@ -0,0 +1,187 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/sklyar/vk-banhammer/internal/entity"
+	"go.uber.org/zap"
+)
+
+// errUnknownAction is returned when outbox contains action of unknown type.
+var errUnknownAction = errors.New("unknown action")
+
+// Outbox keeps pending moderation actions until they are done.
+type Outbox interface {
+	// Put adds or replaces actions.
+	Put(actions ...entity.Action) error
+	// Delete removes action.
+	Delete(id string) error
+	// Due returns actions which should be attempted at the given time.
+	Due(now time.Time) []entity.Action
+}
+
+// Audit keeps decisions about matched comments.
+type Audit interface {
+	// Record stores decision.
+	Record(d entity.Decision) error
+}
+
+// RunOutboxWorker retries pending actions until the context is canceled.
+func (s *Service) RunOutboxWorker(ctx context.Context) {
+	ticker := time.NewTicker(s.retryInterval)
+	defer ticker.Stop()
+
+	for {
+		s.processDueActions(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Service) processDueActions(ctx context.Context) {
+	for _, action := range s.outbox.Due(time.Now()) {
+		if ctx.Err() != nil {
+			return
+		}
+		_ = s.process(ctx, action)
+	}
+}
+
+// punish bans comment author and deletes the comment according to the match punishment.
+// Actions are stored in the outbox first, so the one that failed
+// is retried later by the outbox worker.
+// Match and performed actions are recorded in decision, dry run records the match only.
+func (s *Service) punish(
+	ctx context.Context,
+	d *entity.Decision,
+	comment *entity.Comment,
+	userID int,
+	match entity.Match,
+) error {
+	d.SetMatch(match)
+	if d.DryRun {
+		return nil
+	}
+
+	now := time.Now()
+	var actions []entity.Action
+	switch match.Punishment {
+	case entity.PunishmentBan:
+		actions = append(actions,
+			entity.NewBanAction(comment.OwnerID, userID, match.Reason, now),
+			entity.NewDeleteCommentAction(comment.OwnerID, comment.ID, now),
+		)
+	case entity.PunishmentDelete:
+		actions = append(actions, entity.NewDeleteCommentAction(comment.OwnerID, comment.ID, now))
+	case entity.PunishmentNone:
+		return nil
+	}
+	s.recordPunished(comment.FromID)
+
+	return s.enqueue(ctx, d, actions, now)
+}
+
+// enqueue stores actions in the outbox and attempts them right away.
+// If actions can't be stored, they are still attempted, but without retries.
+// Results of the attempts are recorded in decision, if it is not nil.
+func (s *Service) enqueue(ctx context.Context, d *entity.Decision, actions []entity.Action, now time.Time) error {
+	for i := range actions {
+		// Postpone the worker, the action is attempted right now.
+		actions[i].NextAttemptAt = now.Add(s.retryInterval)
+	}
+	if err := s.outbox.Put(actions...); err != nil {
+		s.logger.Error("failed to store actions, performing them without retries", zap.Error(err))
+	}
+
+	var errs []error
+	for _, action := range actions {
+		err := s.process(ctx, action)
+		d.AddAction(action, err)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// process executes action and updates the outbox according to the result.
+func (s *Service) process(ctx context.Context, action entity.Action) error {
+	err := s.execute(ctx, action)
+	if isIdempotentSuccess(err) {
+		s.logger.Debug("action is already done", zap.String("action_id", action.ID), zap.String("error_class", errorClass(err)))
+		err = nil
+	}
+	if err == nil {
+		if err := s.outbox.Delete(action.ID); err != nil {
+			s.logger.Error("failed to delete action from outbox", zap.Error(err), zap.String("action_id", action.ID))
+		}
+		return nil
+	}
+
+	if !isRetryable(err) {
+		s.logger.Error(
+			"action failed permanently",
+			zap.Error(err),
+			zap.String("error_class", errorClass(err)),
+			zap.Reflect("action", action),
+		)
+		if err := s.outbox.Delete(action.ID); err != nil {
+			s.logger.Error("failed to delete action from outbox", zap.Error(err), zap.String("action_id", action.ID))
+		}
+		return err
+	}
+
+	action.Attempts++
+	action.LastError = err.Error()
+	action.NextAttemptAt = time.Now().Add(s.retryDelay(action.Attempts))
+
+	s.logger.Warn(
+		"action failed, will retry",
+		zap.Error(err),
+		zap.String("error_class", errorClass(err)),
+		zap.Reflect("action", action),
+	)
+	if err := s.outbox.Put(action); err != nil {
+		s.logger.Error("failed to update action in outbox", zap.Error(err), zap.String("action_id", action.ID))
+	}
+
+	return err
+}
+
+func (s *Service) execute(ctx context.Context, action entity.Action) error {
+	switch action.Type {
+	case entity.ActionTypeBan:
+		if err := s.banUser(ctx, action.OwnerID, action.UserID, action.Reason); err != nil {
+			return fmt.Errorf("failed to ban user: %w", err)
+		}
+	case entity.ActionTypeDeleteComment:
+		if err := s.deleteComment(ctx, action.OwnerID, action.CommentID); err != nil {
+			return fmt.Errorf("failed to delete comment: %w", err)
+		}
+	default:
+		return fmt.Errorf("%w: %s", errUnknownAction, action.Type)
+	}
+
+	return nil
+}
+
+// retryDelay returns exponential delay before the next attempt.
+func (s *Service) retryDelay(attempts int) time.Duration {
+	delay := s.retryInterval
+	for i := 1; i < attempts && delay < s.maxRetryInterval; i++ {
+		delay *= 2
+	}
+	if delay > s.maxRetryInterval {
+		delay = s.maxRetryInterval
+	}
+
+	return delay
+}
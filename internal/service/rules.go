@@ -0,0 +1,108 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/sklyar/vk-banhammer/internal/copypaste"
+	"github.com/sklyar/vk-banhammer/internal/entity"
+	"github.com/sklyar/vk-banhammer/internal/flood"
+)
+
+// ruleSet is heuristic rules with the state derived from them.
+// It is never modified after it is applied, reload replaces the whole set.
+type ruleSet struct {
+	heuristics entity.HeuristicRules
+
+	// blacklist maps IDs of explicitly blacklisted users and communities to indexes of their rules.
+	// Communities have negative IDs.
+	blacklist map[int]int
+	// flood counts comments for flood rules.
+	flood *flood.Detector
+	// copyPaste keeps recent fingerprints for each copy-paste rule.
+	copyPaste []*copypaste.Detector
+
+	// userFields is a list of user fields referenced by the rules.
+	userFields string
+	// keepDeactivated is set when rules match deactivated users, so they are not skipped.
+	keepDeactivated bool
+}
+
+func (s *Service) newRuleSet(heuristicRules entity.HeuristicRules) *ruleSet {
+	heuristicRules.LoadProfanity()
+
+	rules := &ruleSet{
+		heuristics: heuristicRules,
+		blacklist:  make(map[int]int),
+		flood:      flood.New(s.floodWindows),
+
+		// bdate is always requested for logs.
+		userFields:      strings.Join(heuristicRules.UserFields("bdate"), ","),
+		keepDeactivated: heuristicRules.ChecksDeactivated(),
+	}
+	for i, r := range heuristicRules.User {
+		if r.ID != nil {
+			rules.blacklist[*r.ID] = i
+		}
+	}
+	for _, r := range heuristicRules.CopyPaste {
+		rules.copyPaste = append(rules.copyPaste, copypaste.New(s.fingerprints, r.Window, r.Distance(), r.MinAuthors))
+	}
+
+	return rules
+}
+
+func (s *Service) loadRules() *ruleSet {
+	s.m.RLock()
+	defer s.m.RUnlock()
+
+	return s.rules
+}
+
+// ResolveBlacklist resolves screen names of blacklisted users and communities into IDs.
+// It must be called before the service starts checking comments.
+func (s *Service) ResolveBlacklist(ctx context.Context) error {
+	return s.resolveBlacklist(ctx, s.loadRules())
+}
+
+func (s *Service) resolveBlacklist(ctx context.Context, rules *ruleSet) error {
+	for i, r := range rules.heuristics.User {
+		if r.ScreenName == nil {
+			continue
+		}
+
+		id, err := s.resolveScreenName(ctx, *r.ScreenName)
+		if err != nil {
+			return fmt.Errorf("failed to resolve screen name %q: %w", *r.ScreenName, err)
+		}
+		rules.blacklist[id] = i
+	}
+
+	return nil
+}
+
+// ReloadRules replaces heuristic rules and trusted users, blacklisted screen names are resolved first.
+// Current rules are kept if resolving fails.
+// Flood and copy-paste rules start counting from scratch.
+// Cached users are dropped if the rules need other user fields.
+func (s *Service) ReloadRules(ctx context.Context, heuristicRules entity.HeuristicRules) error {
+	rules := s.newRuleSet(heuristicRules)
+	if err := s.resolveBlacklist(ctx, rules); err != nil {
+		return err
+	}
+
+	s.m.Lock()
+	prev := s.rules
+	s.rules = rules
+	s.m.Unlock()
+
+	s.allowlist.SetTrusted(heuristicRules.Allowlist)
+	if prev.userFields != rules.userFields || prev.keepDeactivated != rules.keepDeactivated {
+		// Cached users lack new fields, deactivated users may be checked now.
+		s.cache.Purge()
+		s.negativeCache.Purge()
+	}
+
+	return nil
+}
@@ -1,75 +1,376 @@
 package service
 
 import (
+	"context"
 	"errors"
 	"fmt"
-	"strings"
 	"sync"
+	"time"
 
 	"github.com/SevereCloud/vksdk/v2/api"
 	"github.com/SevereCloud/vksdk/v2/object"
-	lru "github.com/hashicorp/golang-lru/v2"
+	"github.com/hashicorp/golang-lru/v2/expirable"
 	"github.com/sklyar/vk-banhammer/internal/entity"
+	"github.com/sklyar/vk-banhammer/internal/flood"
 	"go.uber.org/zap"
 )
 
-const cacheSize = 1000
+const (
+	defaultCacheSize        = 1000
+	defaultCacheTTL         = 10 * time.Minute
+	defaultNegativeCacheTTL = time.Minute
+	defaultAPITimeout       = 5 * time.Second
+	defaultRetryInterval    = 30 * time.Second
+	defaultMaxRetryInterval = time.Hour
+	defaultAllowlistRefresh = time.Hour
+	defaultFloodWindows     = 10000
+	defaultFingerprints     = 10000
+	defaultHistoryTTL       = 24 * time.Hour
+)
+
+// Reasons why comment is not checked, they are reported in decisions.
+const (
+	skippedAllowlist       = "allowlist"
+	skippedDeactivated     = "deactivated"
+	skippedOwnWall         = "own_wall"
+	skippedExemptCommunity = "exempt_community"
+)
 
 var (
 	// ErrUserNotFound is returned when user is not found.
 	ErrUserNotFound = errors.New("user not found")
 
+	// ErrUserDeactivated is returned when user is deleted or banned by VK.
+	ErrUserDeactivated = errors.New("user deactivated")
+
+	// ErrCommunityNotFound is returned when community is not found.
+	ErrCommunityNotFound = errors.New("community not found")
+
+	// ErrCommunityDeactivated is returned when community is deleted or banned by VK.
+	ErrCommunityDeactivated = errors.New("community deactivated")
+
+	// ErrScreenNameNotFound is returned when screen name does not belong to any user or community.
+	ErrScreenNameNotFound = errors.New("screen name not found")
+
 	// ErrBadResponse is returned when VK API returns bad response.
 	ErrBadResponse = errors.New("bad response")
+
+	// ErrRegistrationDateUnknown is returned when registration date can not be requested.
+	ErrRegistrationDateUnknown = errors.New("registration date unknown")
 )
 
 // VkClient is a VK API client.
 // It is used to mock VK API client in tests.
 //
-//go:generate mockgen -source=service.go -package=service -destination=service_mock.go VkClient
+//go:generate mockgen -source=service.go -package=service -destination=service_mock.go VkClient,RegistrationDates
 type VkClient interface {
-	UsersGet(params api.Params) (api.UsersGetResponse, error)
-	GroupsBan(params api.Params) (int, error)
-	WallDeleteComment(params api.Params) (int, error)
+	UsersGet(ctx context.Context, params api.Params) (api.UsersGetResponse, error)
+	UtilsResolveScreenName(ctx context.Context, params api.Params) (api.UtilsResolveScreenNameResponse, error)
+	GroupsGetByID(ctx context.Context, params api.Params) (api.GroupsGetByIDResponse, error)
+	GroupsGetMembers(ctx context.Context, params api.Params) (api.GroupsGetMembersResponse, error)
+	GroupsGetMembersFilterManagers(
+		ctx context.Context,
+		params api.Params,
+	) (api.GroupsGetMembersFilterManagersResponse, error)
+	GroupsBan(ctx context.Context, params api.Params) (int, error)
+	WallDeleteComment(ctx context.Context, params api.Params) (int, error)
+	UtilsCheckLink(ctx context.Context, params api.Params) (api.UtilsCheckLinkResponse, error)
+}
+
+// RegistrationDates returns registration dates of users, they are not available in VK API.
+type RegistrationDates interface {
+	RegistrationDate(ctx context.Context, userID int) (time.Time, error)
+}
+
+// Config is a banhammer service config.
+// Zero values are replaced with defaults.
+type Config struct {
+	// GroupID is an ID of the moderated community, its managers and members are refreshed in the allowlist.
+	// Allowlist is not refreshed if it is zero.
+	GroupID int
+	// AllowlistRefreshInterval is an interval between allowlist refreshes.
+	AllowlistRefreshInterval time.Duration
+
+	// CacheSize is a maximum number of users and communities kept in each of the caches.
+	CacheSize int
+	// CacheTTL is a lifetime of a cached user or community.
+	CacheTTL time.Duration
+	// NegativeCacheTTL is a lifetime of a cached not found or deactivated user or community,
+	// and of a failed registration date lookup.
+	NegativeCacheTTL time.Duration
+
+	// FloodWindows is a maximum number of sliding windows kept by flood rules.
+	FloodWindows int
+	// Fingerprints is a maximum number of recent comment fingerprints kept by each copy-paste rule.
+	Fingerprints int
+	// HistoryTTL is a lifetime of comment history of a user, history of CacheSize users is kept.
+	HistoryTTL time.Duration
+
+	// UsersGetTimeout is a timeout of users.get VK API call.
+	UsersGetTimeout time.Duration
+	// ResolveScreenNameTimeout is a timeout of utils.resolveScreenName VK API call.
+	ResolveScreenNameTimeout time.Duration
+	// GroupsGetByIDTimeout is a timeout of groups.getById VK API call.
+	GroupsGetByIDTimeout time.Duration
+	// GroupsGetMembersTimeout is a timeout of groups.getMembers VK API call.
+	GroupsGetMembersTimeout time.Duration
+	// GroupsBanTimeout is a timeout of groups.ban VK API call.
+	GroupsBanTimeout time.Duration
+	// WallDeleteCommentTimeout is a timeout of wall.deleteComment VK API call.
+	WallDeleteCommentTimeout time.Duration
+	// CheckLinkTimeout is a timeout of utils.checkLink VK API call.
+	CheckLinkTimeout time.Duration
+
+	// RetryInterval is an interval between outbox worker runs
+	// and the initial delay before a failed action is retried.
+	RetryInterval time.Duration
+	// MaxRetryInterval is a maximum delay before a failed action is retried.
+	MaxRetryInterval time.Duration
+}
+
+func (c *Config) setDefaults() {
+	if c.AllowlistRefreshInterval <= 0 {
+		c.AllowlistRefreshInterval = defaultAllowlistRefresh
+	}
+	if c.CacheSize <= 0 {
+		c.CacheSize = defaultCacheSize
+	}
+	if c.CacheTTL <= 0 {
+		c.CacheTTL = defaultCacheTTL
+	}
+	if c.NegativeCacheTTL <= 0 {
+		c.NegativeCacheTTL = defaultNegativeCacheTTL
+	}
+	if c.FloodWindows <= 0 {
+		c.FloodWindows = defaultFloodWindows
+	}
+	if c.Fingerprints <= 0 {
+		c.Fingerprints = defaultFingerprints
+	}
+	if c.HistoryTTL <= 0 {
+		c.HistoryTTL = defaultHistoryTTL
+	}
+	if c.UsersGetTimeout <= 0 {
+		c.UsersGetTimeout = defaultAPITimeout
+	}
+	if c.ResolveScreenNameTimeout <= 0 {
+		c.ResolveScreenNameTimeout = defaultAPITimeout
+	}
+	if c.GroupsGetByIDTimeout <= 0 {
+		c.GroupsGetByIDTimeout = defaultAPITimeout
+	}
+	if c.GroupsGetMembersTimeout <= 0 {
+		c.GroupsGetMembersTimeout = defaultAPITimeout
+	}
+	if c.GroupsBanTimeout <= 0 {
+		c.GroupsBanTimeout = defaultAPITimeout
+	}
+	if c.WallDeleteCommentTimeout <= 0 {
+		c.WallDeleteCommentTimeout = defaultAPITimeout
+	}
+	if c.CheckLinkTimeout <= 0 {
+		c.CheckLinkTimeout = defaultAPITimeout
+	}
+	if c.RetryInterval <= 0 {
+		c.RetryInterval = defaultRetryInterval
+	}
+	if c.MaxRetryInterval <= 0 {
+		c.MaxRetryInterval = defaultMaxRetryInterval
+	}
 }
 
 // Service is a banhammer service.
 type Service struct {
-	heuristicRules entity.HeuristicRules
-	client         VkClient
+	client            VkClient
+	registrationDates RegistrationDates
+	outbox            Outbox
+	allowlist         Allowlist
+	audit             Audit
+
+	groupID                  int
+	allowlistRefreshInterval time.Duration
+
+	// rules are heuristic rules with their state, they are replaced on reload.
+	rules        *ruleSet
+	floodWindows int
+	fingerprints int
+
+	// history keeps comment history of users and communities for expression rules.
+	history  *expirable.LRU[int, entity.CommentHistory]
+	historyM sync.Mutex
+
+	// cache keeps users fetched from VK API.
+	cache *expirable.LRU[int, *object.UsersUser]
+	// groupCache keeps communities fetched from VK API.
+	groupCache *expirable.LRU[int, *object.GroupsGroup]
+	// negativeCache keeps errors for users and communities that are not found or deactivated,
+	// so we do not ask VK API about them on every comment.
+	// Communities are stored by negative ID.
+	negativeCache *expirable.LRU[int, error]
+	// linkCache keeps resolved short links.
+	linkCache *expirable.LRU[string, string]
+	// registrationCache keeps registration dates of users and failed lookups.
+	registrationCache *expirable.LRU[int, registration]
+	m                 sync.RWMutex
+
+	// registrationFailureTTL is a lifetime of a cached failed registration date lookup.
+	registrationFailureTTL time.Duration
 
-	cache *lru.Cache[int, *object.UsersUser]
-	m     sync.RWMutex
+	usersGetTimeout          time.Duration
+	resolveScreenNameTimeout time.Duration
+	groupsGetByIDTimeout     time.Duration
+	groupsGetMembersTimeout  time.Duration
+	groupsBanTimeout         time.Duration
+	wallDeleteCommentTimeout time.Duration
+	checkLinkTimeout         time.Duration
+
+	retryInterval    time.Duration
+	maxRetryInterval time.Duration
 
 	logger *zap.Logger
 }
 
 // NewService creates a new banhammer service.
-func NewService(logger *zap.Logger, client VkClient, heuristicRules entity.HeuristicRules) *Service {
-	cache, err := lru.New[int, *object.UsersUser](cacheSize)
-	if err != nil {
-		panic(err)
-	}
+// Account age is never known if registrationDates is nil.
+func NewService(
+	logger *zap.Logger,
+	client VkClient,
+	registrationDates RegistrationDates,
+	outbox Outbox,
+	allowlist Allowlist,
+	audit Audit,
+	heuristicRules entity.HeuristicRules,
+	cfg Config,
+) *Service {
+	cfg.setDefaults()
+
+	s := &Service{
+		client:            client,
+		registrationDates: registrationDates,
+		outbox:            outbox,
+		allowlist:         allowlist,
+		audit:             audit,
+		floodWindows:      cfg.FloodWindows,
+		fingerprints:      cfg.Fingerprints,
+		history:           expirable.NewLRU[int, entity.CommentHistory](cfg.CacheSize, nil, cfg.HistoryTTL),
+		cache:             expirable.NewLRU[int, *object.UsersUser](cfg.CacheSize, nil, cfg.CacheTTL),
+		groupCache:        expirable.NewLRU[int, *object.GroupsGroup](cfg.CacheSize, nil, cfg.CacheTTL),
+		negativeCache:     expirable.NewLRU[int, error](cfg.CacheSize, nil, cfg.NegativeCacheTTL),
+		// Short links and registration dates do not change, they are kept until evicted.
+		// Failed registration date lookups expire after registrationFailureTTL.
+		linkCache:         expirable.NewLRU[string, string](cfg.CacheSize, nil, 0),
+		registrationCache: expirable.NewLRU[int, registration](cfg.CacheSize, nil, 0),
+		m:                 sync.RWMutex{},
+
+		registrationFailureTTL: cfg.NegativeCacheTTL,
+
+		groupID:                  cfg.GroupID,
+		allowlistRefreshInterval: cfg.AllowlistRefreshInterval,
+
+		usersGetTimeout:          cfg.UsersGetTimeout,
+		resolveScreenNameTimeout: cfg.ResolveScreenNameTimeout,
+		groupsGetByIDTimeout:     cfg.GroupsGetByIDTimeout,
+		groupsGetMembersTimeout:  cfg.GroupsGetMembersTimeout,
+		groupsBanTimeout:         cfg.GroupsBanTimeout,
+		wallDeleteCommentTimeout: cfg.WallDeleteCommentTimeout,
+		checkLinkTimeout:         cfg.CheckLinkTimeout,
 
-	return &Service{
-		heuristicRules: heuristicRules,
-		client:         client,
-		cache:          cache,
-		m:              sync.RWMutex{},
-		logger:         logger,
+		retryInterval:    cfg.RetryInterval,
+		maxRetryInterval: cfg.MaxRetryInterval,
+
+		logger: logger,
 	}
+	s.rules = s.newRuleSet(heuristicRules)
+
+	return s
 }
 
 // CheckComment checks comment and ban user if needed.
-func (s *Service) CheckComment(comment *entity.Comment) (entity.BanReason, error) {
-	user, err := s.getUserByID(comment.FromID)
+// Decision lists evaluated rules and performed actions, it is returned on error too.
+// Matched decisions are logged and audited.
+func (s *Service) CheckComment(ctx context.Context, comment *entity.Comment) (entity.Decision, error) {
+	d := entity.NewDecision(comment)
+	err := s.checkComment(ctx, comment, &d)
+
+	if !d.Matched() {
+		s.logger.Debug("comment checked", zap.Reflect("decision", d))
+		return d, err
+	}
+
+	s.logger.Info(
+		"comment matched",
+		zap.Reflect("decision", d),
+		zap.String("text", comment.Text),
+		zap.String("normalized_text", comment.NormalizedText()),
+	)
+	if err := s.audit.Record(d); err != nil {
+		s.logger.Error("failed to audit decision", zap.Error(err), zap.Int("comment_id", comment.ID))
+	}
+
+	return d, err
+}
+
+// ExplainComment evaluates rules for comment and returns the decision without punishing.
+// Comment is not recorded, so it does not count in flood, copy-paste and history of the author.
+func (s *Service) ExplainComment(ctx context.Context, comment *entity.Comment) (entity.Decision, error) {
+	d := entity.NewDecision(comment)
+	d.DryRun = true
+	err := s.checkComment(ctx, comment, &d)
+	s.logger.Debug("comment explained", zap.Reflect("decision", d))
+
+	return d, err
+}
+
+// checkComment checks comment and punishes its author, unless decision is a dry run.
+func (s *Service) checkComment(ctx context.Context, comment *entity.Comment, d *entity.Decision) error {
+	if s.allowlist.Contains(comment.FromID, time.Now()) {
+		d.Skipped = skippedAllowlist
+		return nil
+	}
+
+	// Comment is checked with the same rules, even if they are reloaded meanwhile.
+	rules := s.loadRules()
+	// Skipped communities are neither punished nor counted by flood and copy-paste rules.
+	if skipped := communitySkipReason(rules, comment); skipped != "" {
+		d.Skipped = skipped
+		return nil
+	}
+	var history entity.CommentHistory
+	if d.DryRun {
+		history = s.commentHistory(comment.FromID)
+	} else {
+		history = s.recordComment(comment.FromID)
+	}
+
+	if i, exists := rules.blacklist[comment.FromID]; exists {
+		d.AddRule("user", i, true, 1, map[string]any{"id": comment.FromID})
+		return s.punish(ctx, d, comment, comment.FromID, entity.BlacklistMatch())
+	}
+	if match, matched := s.checkFlood(rules, comment, time.Now(), d); matched {
+		return s.punish(ctx, d, comment, comment.FromID, match)
+	}
+	if match, matched := s.checkCopyPaste(ctx, rules, comment, time.Now(), d); matched {
+		return s.punish(ctx, d, comment, comment.FromID, match)
+	}
+
+	if comment.FromID < 0 {
+		return s.checkCommunityComment(ctx, rules, comment, history, d)
+	}
+
+	user, err := s.getUserByID(ctx, rules, comment.FromID)
 	if err != nil {
-		// Ignore comments from groups.
-		if isCommentFromGroup(comment) {
-			return entity.BanReasonNone, nil
+		// Deactivated users can not comment anymore, nothing to do.
+		if errors.Is(err, ErrUserDeactivated) {
+			d.Skipped = skippedDeactivated
+			return nil
 		}
-		s.logger.Error("failed to get user", zap.Error(err), zap.Reflect("comment", comment))
-		return entity.BanReasonNone, fmt.Errorf("failed to get user: %w", err)
+		s.logger.Error(
+			"failed to get user",
+			zap.Error(err),
+			zap.String("error_class", errorClass(err)),
+			zap.Reflect("comment", comment),
+		)
+		return fmt.Errorf("failed to get user: %w", err)
 	}
 
 	s.logger.Debug(
@@ -78,69 +379,184 @@ func (s *Service) CheckComment(comment *entity.Comment) (entity.BanReason, error
 		zap.String("first_name", user.FirstName),
 		zap.String("last_name", user.LastName),
 		zap.String("bday", user.Bdate),
+		zap.String("text", comment.Text),
+		zap.String("normalized_text", comment.NormalizedText()),
 	)
 
-	reason, shouldBan := s.heuristicRules.Check(user)
-	if shouldBan {
-		if err := s.banUser(comment.OwnerID, user.ID, reason); err != nil {
-			return reason, fmt.Errorf("failed to ban user: %w", err)
+	match, matched := rules.heuristics.Check(user, d)
+	if !matched {
+		match, matched = rules.heuristics.CheckComment(comment, d)
+	}
+	if !matched {
+		match, matched = rules.heuristics.CheckExpressions(user, comment, history, d)
+	}
+	if !matched {
+		match, matched = s.checkLinks(ctx, rules, comment, d)
+	}
+	if !matched {
+		return nil
+	}
+
+	return s.punish(ctx, d, comment, user.ID, match)
+}
+
+// checkFlood records comment in flood windows and checks flood rules.
+// Dry run only counts comments.
+func (s *Service) checkFlood(
+	rules *ruleSet,
+	comment *entity.Comment,
+	now time.Time,
+	d *entity.Decision,
+) (entity.Match, bool) {
+	var (
+		match   entity.Match
+		matched bool
+	)
+	// Comment is recorded in every window, even if an earlier rule matched.
+	for i, r := range rules.heuristics.Flood {
+		key := flood.Key{Rule: i, FromID: comment.FromID}
+		if r.PerPost {
+			key.PostID = comment.PostID
 		}
 
-		if err := s.deleteComment(comment); err != nil {
-			return reason, fmt.Errorf("failed to delete comment: %w", err)
+		var count int
+		if d.DryRun {
+			count = rules.flood.Count(key, now, r.Window, r.MaxComments)
+		} else {
+			count = rules.flood.Hit(key, now, r.Window, r.MaxComments)
+		}
+		d.AddRule("flood", i, count > r.MaxComments, 0, map[string]any{"comments": count})
+		if count > r.MaxComments && !matched {
+			match, matched = r.Match(), true
 		}
 	}
 
-	return reason, nil
+	return match, matched
+}
+
+// InvalidateUser removes user from the caches.
+// Negative ID removes community.
+func (s *Service) InvalidateUser(userID int) {
+	if userID < 0 {
+		s.groupCache.Remove(-userID)
+	} else {
+		s.cache.Remove(userID)
+	}
+	s.negativeCache.Remove(userID)
 }
 
-func (s *Service) getUserByID(userID int) (*object.UsersUser, error) {
+// InvalidateCache removes all users and communities from the caches.
+func (s *Service) InvalidateCache() {
+	s.cache.Purge()
+	s.groupCache.Purge()
+	s.negativeCache.Purge()
+}
+
+func (s *Service) getUserByID(ctx context.Context, rules *ruleSet, userID int) (*object.UsersUser, error) {
 	u, exists := s.cache.Get(userID)
 	if exists {
 		return u, nil
 	}
+	if err, exists := s.negativeCache.Get(userID); exists {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.usersGetTimeout)
+	defer cancel()
 
 	users, err := s.client.UsersGet(
+		ctx,
 		api.Params{
 			"user_ids": userID,
-			"fields":   "bdate",
+			"fields":   rules.userFields,
 		},
 	)
 	if err != nil {
-		return nil, err
+		return nil, classifyError(methodUsersGet, err)
 	}
 	if len(users) == 0 {
+		s.negativeCache.Add(userID, ErrUserNotFound)
 		return nil, ErrUserNotFound
 	}
 
 	u = &users[0]
+	if u.Deactivated != "" && !rules.keepDeactivated {
+		s.negativeCache.Add(userID, ErrUserDeactivated)
+		return nil, ErrUserDeactivated
+	}
 	s.cache.Add(userID, u)
 
 	return u, nil
 }
 
-func (s *Service) banUser(groupID, userID int, reason entity.BanReason) error {
+// resolveScreenName returns ID of user or negative ID of community by screen name.
+func (s *Service) resolveScreenName(ctx context.Context, screenName string) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.resolveScreenNameTimeout)
+	defer cancel()
+
+	res, err := s.client.UtilsResolveScreenName(ctx, api.Params{"screen_name": screenName})
+	if err != nil {
+		return 0, classifyError(methodUtilsResolveScreenName, err)
+	}
+
+	switch res.Type {
+	case object.UtilsDomainResolvedTypeUser:
+		return res.ObjectID, nil
+	case object.UtilsDomainResolvedTypeGroup:
+		return -res.ObjectID, nil
+	case "":
+		return 0, ErrScreenNameNotFound
+	default:
+		return 0, fmt.Errorf("%w: %s", ErrScreenNameNotFound, res.Type)
+	}
+}
+
+// VK ban reasons shown to the banned user.
+const (
+	vkBanReasonSpam           = 1
+	vkBanReasonStrongLanguage = 3
+)
+
+// vkBanReasons maps ban reasons to VK ban reasons, other reasons are sent without it.
+var vkBanReasons = map[entity.BanReason]int{
+	entity.BanReasonProfanity:   vkBanReasonStrongLanguage,
+	entity.BanReasonSpamPattern: vkBanReasonSpam,
+}
+
+func (s *Service) banUser(ctx context.Context, groupID, userID int, reason entity.BanReason) error {
 	req := api.Params{
 		"group_id":        -groupID, // group id should be negative.
 		"owner_id":        userID,
 		"comment":         string(reason),
 		"comment_visible": 0,
 	}
-	return s.do(s.client.GroupsBan, req)
+	if vkReason, ok := vkBanReasons[reason]; ok {
+		req["reason"] = vkReason
+	}
+	return s.do(ctx, methodGroupsBan, s.groupsBanTimeout, s.client.GroupsBan, req)
 }
 
-func (s *Service) deleteComment(comment *entity.Comment) error {
+func (s *Service) deleteComment(ctx context.Context, ownerID, commentID int) error {
 	req := api.Params{
-		"owner_id":   comment.OwnerID,
-		"comment_id": comment.ID,
+		"owner_id":   ownerID,
+		"comment_id": commentID,
 	}
-	return s.do(s.client.WallDeleteComment, req)
+	return s.do(ctx, methodWallDeleteComment, s.wallDeleteCommentTimeout, s.client.WallDeleteComment, req)
 }
 
-func (s *Service) do(fn func(api.Params) (int, error), params api.Params) error {
-	res, err := fn(params)
+func (s *Service) do(
+	ctx context.Context,
+	method string,
+	timeout time.Duration,
+	fn func(context.Context, api.Params) (int, error),
+	params api.Params,
+) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	res, err := fn(ctx, params)
 	if err != nil {
-		return err
+		return classifyError(method, err)
 	}
 
 	if res != 1 {
@@ -149,7 +565,3 @@ func (s *Service) do(fn func(api.Params) (int, error), params api.Params) error
 
 	return nil
 }
-
-func isCommentFromGroup(comment *entity.Comment) bool {
-	return strings.HasPrefix(comment.Text, "[club") || comment.FromID < 0
-}
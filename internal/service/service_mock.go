@@ -5,7 +5,9 @@
 package service
 
 import (
+	context "context"
 	reflect "reflect"
+	time "time"
 
 	api "github.com/SevereCloud/vksdk/v2/api"
 	gomock "github.com/golang/mock/gomock"
@@ -35,46 +37,159 @@ func (m *MockVkClient) EXPECT() *MockVkClientMockRecorder {
 }
 
 // GroupsBan mocks base method.
-func (m *MockVkClient) GroupsBan(params api.Params) (int, error) {
+func (m *MockVkClient) GroupsBan(ctx context.Context, params api.Params) (int, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GroupsBan", params)
+	ret := m.ctrl.Call(m, "GroupsBan", ctx, params)
 	ret0, _ := ret[0].(int)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GroupsBan indicates an expected call of GroupsBan.
-func (mr *MockVkClientMockRecorder) GroupsBan(params interface{}) *gomock.Call {
+func (mr *MockVkClientMockRecorder) GroupsBan(ctx, params interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GroupsBan", reflect.TypeOf((*MockVkClient)(nil).GroupsBan), params)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GroupsBan", reflect.TypeOf((*MockVkClient)(nil).GroupsBan), ctx, params)
+}
+
+// GroupsGetByID mocks base method.
+func (m *MockVkClient) GroupsGetByID(ctx context.Context, params api.Params) (api.GroupsGetByIDResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GroupsGetByID", ctx, params)
+	ret0, _ := ret[0].(api.GroupsGetByIDResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GroupsGetByID indicates an expected call of GroupsGetByID.
+func (mr *MockVkClientMockRecorder) GroupsGetByID(ctx, params interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GroupsGetByID", reflect.TypeOf((*MockVkClient)(nil).GroupsGetByID), ctx, params)
+}
+
+// GroupsGetMembers mocks base method.
+func (m *MockVkClient) GroupsGetMembers(ctx context.Context, params api.Params) (api.GroupsGetMembersResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GroupsGetMembers", ctx, params)
+	ret0, _ := ret[0].(api.GroupsGetMembersResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GroupsGetMembers indicates an expected call of GroupsGetMembers.
+func (mr *MockVkClientMockRecorder) GroupsGetMembers(ctx, params interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GroupsGetMembers", reflect.TypeOf((*MockVkClient)(nil).GroupsGetMembers), ctx, params)
+}
+
+// GroupsGetMembersFilterManagers mocks base method.
+func (m *MockVkClient) GroupsGetMembersFilterManagers(ctx context.Context, params api.Params) (api.GroupsGetMembersFilterManagersResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GroupsGetMembersFilterManagers", ctx, params)
+	ret0, _ := ret[0].(api.GroupsGetMembersFilterManagersResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GroupsGetMembersFilterManagers indicates an expected call of GroupsGetMembersFilterManagers.
+func (mr *MockVkClientMockRecorder) GroupsGetMembersFilterManagers(ctx, params interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GroupsGetMembersFilterManagers", reflect.TypeOf((*MockVkClient)(nil).GroupsGetMembersFilterManagers), ctx, params)
 }
 
 // UsersGet mocks base method.
-func (m *MockVkClient) UsersGet(params api.Params) (api.UsersGetResponse, error) {
+func (m *MockVkClient) UsersGet(ctx context.Context, params api.Params) (api.UsersGetResponse, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UsersGet", params)
+	ret := m.ctrl.Call(m, "UsersGet", ctx, params)
 	ret0, _ := ret[0].(api.UsersGetResponse)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // UsersGet indicates an expected call of UsersGet.
-func (mr *MockVkClientMockRecorder) UsersGet(params interface{}) *gomock.Call {
+func (mr *MockVkClientMockRecorder) UsersGet(ctx, params interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UsersGet", reflect.TypeOf((*MockVkClient)(nil).UsersGet), ctx, params)
+}
+
+// UtilsCheckLink mocks base method.
+func (m *MockVkClient) UtilsCheckLink(ctx context.Context, params api.Params) (api.UtilsCheckLinkResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UtilsCheckLink", ctx, params)
+	ret0, _ := ret[0].(api.UtilsCheckLinkResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UtilsCheckLink indicates an expected call of UtilsCheckLink.
+func (mr *MockVkClientMockRecorder) UtilsCheckLink(ctx, params interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UsersGet", reflect.TypeOf((*MockVkClient)(nil).UsersGet), params)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UtilsCheckLink", reflect.TypeOf((*MockVkClient)(nil).UtilsCheckLink), ctx, params)
+}
+
+// UtilsResolveScreenName mocks base method.
+func (m *MockVkClient) UtilsResolveScreenName(ctx context.Context, params api.Params) (api.UtilsResolveScreenNameResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UtilsResolveScreenName", ctx, params)
+	ret0, _ := ret[0].(api.UtilsResolveScreenNameResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UtilsResolveScreenName indicates an expected call of UtilsResolveScreenName.
+func (mr *MockVkClientMockRecorder) UtilsResolveScreenName(ctx, params interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UtilsResolveScreenName", reflect.TypeOf((*MockVkClient)(nil).UtilsResolveScreenName), ctx, params)
 }
 
 // WallDeleteComment mocks base method.
-func (m *MockVkClient) WallDeleteComment(params api.Params) (int, error) {
+func (m *MockVkClient) WallDeleteComment(ctx context.Context, params api.Params) (int, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "WallDeleteComment", params)
+	ret := m.ctrl.Call(m, "WallDeleteComment", ctx, params)
 	ret0, _ := ret[0].(int)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // WallDeleteComment indicates an expected call of WallDeleteComment.
-func (mr *MockVkClientMockRecorder) WallDeleteComment(params interface{}) *gomock.Call {
+func (mr *MockVkClientMockRecorder) WallDeleteComment(ctx, params interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WallDeleteComment", reflect.TypeOf((*MockVkClient)(nil).WallDeleteComment), ctx, params)
+}
+
+// MockRegistrationDates is a mock of RegistrationDates interface.
+type MockRegistrationDates struct {
+	ctrl     *gomock.Controller
+	recorder *MockRegistrationDatesMockRecorder
+}
+
+// MockRegistrationDatesMockRecorder is the mock recorder for MockRegistrationDates.
+type MockRegistrationDatesMockRecorder struct {
+	mock *MockRegistrationDates
+}
+
+// NewMockRegistrationDates creates a new mock instance.
+func NewMockRegistrationDates(ctrl *gomock.Controller) *MockRegistrationDates {
+	mock := &MockRegistrationDates{ctrl: ctrl}
+	mock.recorder = &MockRegistrationDatesMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockRegistrationDates) EXPECT() *MockRegistrationDatesMockRecorder {
+	return m.recorder
+}
+
+// RegistrationDate mocks base method.
+func (m *MockRegistrationDates) RegistrationDate(ctx context.Context, userID int) (time.Time, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RegistrationDate", ctx, userID)
+	ret0, _ := ret[0].(time.Time)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RegistrationDate indicates an expected call of RegistrationDate.
+func (mr *MockRegistrationDatesMockRecorder) RegistrationDate(ctx, userID interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WallDeleteComment", reflect.TypeOf((*MockVkClient)(nil).WallDeleteComment), params)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RegistrationDate", reflect.TypeOf((*MockRegistrationDates)(nil).RegistrationDate), ctx, userID)
 }
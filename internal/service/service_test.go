@@ -1,18 +1,28 @@
 package service
 
 import (
+	"context"
 	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/SevereCloud/vksdk/v2/api"
 	"github.com/SevereCloud/vksdk/v2/object"
 	"github.com/golang/mock/gomock"
+	"github.com/sklyar/vk-banhammer/internal/allowlist"
+	"github.com/sklyar/vk-banhammer/internal/audit"
 	"github.com/sklyar/vk-banhammer/internal/entity"
+	"github.com/sklyar/vk-banhammer/internal/outbox"
 	"go.uber.org/zap"
 )
 
 type dependencies struct {
-	client *MockVkClient
+	client            *MockVkClient
+	registrationDates *MockRegistrationDates
 }
 
 func TestServiceCheckComment(t *testing.T) {
@@ -41,7 +51,7 @@ func TestServiceCheckComment(t *testing.T) {
 			heuristicRules: entity.HeuristicRules{},
 			setup: func(d *dependencies) {
 				d.client.EXPECT().
-					UsersGet(api.Params{"user_ids": 87524863, "fields": "bdate"}).
+					UsersGet(gomock.Any(), api.Params{"user_ids": 87524863, "fields": "bdate"}).
 					Return(api.UsersGetResponse{}, errors.New("some error"))
 			},
 			want:    entity.BanReasonNone,
@@ -53,7 +63,7 @@ func TestServiceCheckComment(t *testing.T) {
 			heuristicRules: entity.HeuristicRules{},
 			setup: func(d *dependencies) {
 				d.client.EXPECT().
-					UsersGet(api.Params{"user_ids": 87524863, "fields": "bdate"}).
+					UsersGet(gomock.Any(), api.Params{"user_ids": 87524863, "fields": "bdate"}).
 					Return(api.UsersGetResponse{}, nil)
 			},
 			want:    entity.BanReasonNone,
@@ -75,7 +85,7 @@ func TestServiceCheckComment(t *testing.T) {
 				}
 
 				d.client.EXPECT().
-					UsersGet(api.Params{"user_ids": 87524863, "fields": "bdate"}).
+					UsersGet(gomock.Any(), api.Params{"user_ids": 87524863, "fields": "bdate"}).
 					Return([]object.UsersUser{user}, nil)
 			},
 			want:    entity.BanReasonNone,
@@ -97,17 +107,17 @@ func TestServiceCheckComment(t *testing.T) {
 				}
 
 				d.client.EXPECT().
-					UsersGet(api.Params{"user_ids": 87524863, "fields": "bdate"}).
+					UsersGet(gomock.Any(), api.Params{"user_ids": 87524863, "fields": "bdate"}).
 					Return([]object.UsersUser{user}, nil)
 
-				d.client.EXPECT().GroupsBan(api.Params{
+				d.client.EXPECT().GroupsBan(gomock.Any(), api.Params{
 					"group_id":        61061413,
 					"owner_id":        87524863,
 					"comment":         string(entity.BanReasonPersonNonGrata),
 					"comment_visible": 0,
 				}).Return(1, nil)
 
-				d.client.EXPECT().WallDeleteComment(api.Params{
+				d.client.EXPECT().WallDeleteComment(gomock.Any(), api.Params{
 					"owner_id":   -61061413,
 					"comment_id": 1,
 				}).Return(1, nil)
@@ -132,17 +142,17 @@ func TestServiceCheckComment(t *testing.T) {
 				}
 
 				d.client.EXPECT().
-					UsersGet(api.Params{"user_ids": 87524863, "fields": "bdate"}).
+					UsersGet(gomock.Any(), api.Params{"user_ids": 87524863, "fields": "bdate"}).
 					Return([]object.UsersUser{user}, nil)
 
-				d.client.EXPECT().GroupsBan(api.Params{
+				d.client.EXPECT().GroupsBan(gomock.Any(), api.Params{
 					"group_id":        61061413,
 					"owner_id":        87524863,
 					"comment":         string(entity.BanReasonPersonNonGrata),
 					"comment_visible": 0,
 				}).Return(1, nil)
 
-				d.client.EXPECT().WallDeleteComment(api.Params{
+				d.client.EXPECT().WallDeleteComment(gomock.Any(), api.Params{
 					"owner_id":   -61061413,
 					"comment_id": 1,
 				}).Return(1, nil)
@@ -150,6 +160,60 @@ func TestServiceCheckComment(t *testing.T) {
 			want:    entity.BanReasonPersonNonGrata,
 			wantErr: false,
 		},
+		{
+			name:    "user banned by profile fields",
+			comment: defaultComment,
+			heuristicRules: entity.HeuristicRules{
+				PersonNonGrata: []entity.HeuristicPersonNonGrataRule{
+					{City: toPtr("moscow"), Sex: toPtr("male"), Status: toPtr("crypto")},
+				},
+			},
+			setup: func(d *dependencies) {
+				user := object.UsersUser{
+					ID:        87524863,
+					FirstName: "Bob",
+					LastName:  "Marley",
+					City:      object.BaseObject{ID: 1, Title: "Moscow"},
+					Sex:       2,
+					Status:    "Best Crypto signals",
+				}
+
+				d.client.EXPECT().
+					UsersGet(gomock.Any(), api.Params{"user_ids": 87524863, "fields": "bdate,city,sex,status"}).
+					Return([]object.UsersUser{user}, nil)
+
+				d.client.EXPECT().GroupsBan(gomock.Any(), gomock.Any()).Return(1, nil)
+				d.client.EXPECT().WallDeleteComment(gomock.Any(), gomock.Any()).Return(1, nil)
+			},
+			want:    entity.BanReasonPersonNonGrata,
+			wantErr: false,
+		},
+		{
+			name:    "deactivated user banned",
+			comment: defaultComment,
+			heuristicRules: entity.HeuristicRules{
+				PersonNonGrata: []entity.HeuristicPersonNonGrataRule{
+					{Deactivated: toPtr("banned")},
+				},
+			},
+			setup: func(d *dependencies) {
+				user := object.UsersUser{
+					ID:          87524863,
+					FirstName:   "Bob",
+					LastName:    "Marley",
+					Deactivated: "banned",
+				}
+
+				d.client.EXPECT().
+					UsersGet(gomock.Any(), api.Params{"user_ids": 87524863, "fields": "bdate"}).
+					Return([]object.UsersUser{user}, nil)
+
+				d.client.EXPECT().GroupsBan(gomock.Any(), gomock.Any()).Return(1, nil)
+				d.client.EXPECT().WallDeleteComment(gomock.Any(), gomock.Any()).Return(1, nil)
+			},
+			want:    entity.BanReasonPersonNonGrata,
+			wantErr: false,
+		},
 	}
 	for _, tt := range tests {
 		tt := tt
@@ -165,14 +229,14 @@ func TestServiceCheckComment(t *testing.T) {
 				tt.setup(&deps)
 			}
 
-			s := NewService(zap.NewNop(), deps.client, tt.heuristicRules)
-			got, err := s.CheckComment(tt.comment)
+			s := NewService(zap.NewNop(), deps.client, nil, newOutbox(t), newAllowlist(t), newAudit(t, ""), tt.heuristicRules, Config{})
+			got, err := s.CheckComment(context.Background(), tt.comment)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("CheckComment() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
-			if got != tt.want {
-				t.Errorf("CheckComment() got = %v, want %v", got, tt.want)
+			if got.Reason != tt.want {
+				t.Errorf("CheckComment() got = %v, want %v", got.Reason, tt.want)
 			}
 		})
 	}
@@ -203,31 +267,1186 @@ func TestServiceCheckComment_RetrieveUserFromCache(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	deps := dependencies{client: NewMockVkClient(ctrl)}
 	deps.client.EXPECT().
-		UsersGet(api.Params{"user_ids": 87524863, "fields": "bdate"}).
+		UsersGet(gomock.Any(), api.Params{"user_ids": 87524863, "fields": "bdate"}).
 		Return([]object.UsersUser{user}, nil).
 		Times(1)
 
-	s := NewService(zap.NewNop(), deps.client, heuristicRules)
+	s := NewService(zap.NewNop(), deps.client, nil, newOutbox(t), newAllowlist(t), newAudit(t, ""), heuristicRules, Config{})
 
 	// First call should not use cache.
-	got, err := s.CheckComment(comment)
+	got, err := s.CheckComment(context.Background(), comment)
 	if err != nil {
 		t.Errorf("CheckComment() error = %v, wantErr %v", err, false)
 		return
 	}
-	if got != entity.BanReasonNone {
-		t.Errorf("CheckComment() got = %v, want %v", got, entity.BanReasonNone)
+	if got.Reason != entity.BanReasonNone {
+		t.Errorf("CheckComment() got = %v, want %v", got.Reason, entity.BanReasonNone)
 	}
 
 	// Second call should use cache.
-	got, err = s.CheckComment(comment)
+	got, err = s.CheckComment(context.Background(), comment)
 	if err != nil {
 		t.Errorf("CheckComment() error = %v, wantErr %v", err, false)
 		return
 	}
-	if got != entity.BanReasonNone {
-		t.Errorf("CheckComment() got = %v, want %v", got, entity.BanReasonNone)
+	if got.Reason != entity.BanReasonNone {
+		t.Errorf("CheckComment() got = %v, want %v", got.Reason, entity.BanReasonNone)
+	}
+}
+
+func TestServiceCheckComment_NegativeCache(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		users   api.UsersGetResponse
+		wantErr bool
+	}{
+		{
+			name:    "user not found",
+			users:   api.UsersGetResponse{},
+			wantErr: true,
+		},
+		{
+			name: "user deactivated",
+			users: api.UsersGetResponse{
+				{ID: 87524863, FirstName: "DELETED", Deactivated: "deleted"},
+			},
+			wantErr: false,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			comment := &entity.Comment{ID: 1, FromID: 87524863, OwnerID: -61061413}
+
+			ctrl := gomock.NewController(t)
+			deps := dependencies{client: NewMockVkClient(ctrl)}
+			deps.client.EXPECT().
+				UsersGet(gomock.Any(), api.Params{"user_ids": 87524863, "fields": "bdate"}).
+				Return(tt.users, nil).
+				Times(1)
+
+			s := NewService(zap.NewNop(), deps.client, nil, newOutbox(t), newAllowlist(t), newAudit(t, ""), entity.HeuristicRules{}, Config{})
+
+			for i := 0; i < 2; i++ {
+				got, err := s.CheckComment(context.Background(), comment)
+				if (err != nil) != tt.wantErr {
+					t.Errorf("CheckComment() error = %v, wantErr %v", err, tt.wantErr)
+					return
+				}
+				if got.Reason != entity.BanReasonNone {
+					t.Errorf("CheckComment() got = %v, want %v", got.Reason, entity.BanReasonNone)
+				}
+			}
+		})
+	}
+}
+
+func TestServiceCheckComment_CacheExpiration(t *testing.T) {
+	t.Parallel()
+
+	user := object.UsersUser{ID: 87524863, FirstName: "Bob", LastName: "Marley"}
+	comment := &entity.Comment{ID: 1, FromID: 87524863, OwnerID: -61061413}
+
+	ctrl := gomock.NewController(t)
+	deps := dependencies{client: NewMockVkClient(ctrl)}
+	deps.client.EXPECT().
+		UsersGet(gomock.Any(), api.Params{"user_ids": 87524863, "fields": "bdate"}).
+		Return([]object.UsersUser{user}, nil).
+		Times(2)
+
+	s := NewService(zap.NewNop(), deps.client, nil, newOutbox(t), newAllowlist(t), newAudit(t, ""), entity.HeuristicRules{}, Config{CacheTTL: 10 * time.Millisecond})
+
+	if _, err := s.CheckComment(context.Background(), comment); err != nil {
+		t.Fatalf("CheckComment() error = %v", err)
+	}
+
+	time.Sleep(20 * time.Millisecond)
+
+	// Cached user is expired, so it should be requested again.
+	if _, err := s.CheckComment(context.Background(), comment); err != nil {
+		t.Fatalf("CheckComment() error = %v", err)
+	}
+}
+
+func TestServiceInvalidateUser(t *testing.T) {
+	t.Parallel()
+
+	user := object.UsersUser{ID: 87524863, FirstName: "Bob", LastName: "Marley"}
+	comment := &entity.Comment{ID: 1, FromID: 87524863, OwnerID: -61061413}
+
+	ctrl := gomock.NewController(t)
+	deps := dependencies{client: NewMockVkClient(ctrl)}
+	deps.client.EXPECT().
+		UsersGet(gomock.Any(), api.Params{"user_ids": 87524863, "fields": "bdate"}).
+		Return([]object.UsersUser{user}, nil).
+		Times(3)
+
+	s := NewService(zap.NewNop(), deps.client, nil, newOutbox(t), newAllowlist(t), newAudit(t, ""), entity.HeuristicRules{}, Config{})
+
+	if _, err := s.CheckComment(context.Background(), comment); err != nil {
+		t.Fatalf("CheckComment() error = %v", err)
+	}
+
+	s.InvalidateUser(user.ID)
+	if _, err := s.CheckComment(context.Background(), comment); err != nil {
+		t.Fatalf("CheckComment() error = %v", err)
+	}
+
+	s.InvalidateCache()
+	if _, err := s.CheckComment(context.Background(), comment); err != nil {
+		t.Fatalf("CheckComment() error = %v", err)
+	}
+}
+
+func TestServiceCheckComment_Timeout(t *testing.T) {
+	t.Parallel()
+
+	comment := &entity.Comment{ID: 1, FromID: 87524863, OwnerID: -61061413}
+
+	ctrl := gomock.NewController(t)
+	deps := dependencies{client: NewMockVkClient(ctrl)}
+	deps.client.EXPECT().
+		UsersGet(gomock.Any(), api.Params{"user_ids": 87524863, "fields": "bdate"}).
+		DoAndReturn(func(ctx context.Context, _ api.Params) (api.UsersGetResponse, error) {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		})
+
+	s := NewService(zap.NewNop(), deps.client, nil, newOutbox(t), newAllowlist(t), newAudit(t, ""), entity.HeuristicRules{}, Config{UsersGetTimeout: 10 * time.Millisecond})
+
+	_, err := s.CheckComment(context.Background(), comment)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("CheckComment() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestServiceCheckComment_PartialFailure(t *testing.T) {
+	t.Parallel()
+
+	user := object.UsersUser{ID: 87524863, FirstName: "Bob", LastName: "Marley"}
+	comment := &entity.Comment{ID: 1, FromID: 87524863, OwnerID: -61061413}
+	heuristicRules := entity.HeuristicRules{
+		PersonNonGrata: []entity.HeuristicPersonNonGrataRule{
+			{Name: toPtr("Bob Marley")},
+		},
+	}
+	banParams := api.Params{
+		"group_id":        61061413,
+		"owner_id":        87524863,
+		"comment":         string(entity.BanReasonPersonNonGrata),
+		"comment_visible": 0,
+	}
+	deleteParams := api.Params{"owner_id": -61061413, "comment_id": 1}
+
+	ctrl := gomock.NewController(t)
+	deps := dependencies{client: NewMockVkClient(ctrl)}
+	deps.client.EXPECT().
+		UsersGet(gomock.Any(), api.Params{"user_ids": 87524863, "fields": "bdate"}).
+		Return([]object.UsersUser{user}, nil)
+	gomock.InOrder(
+		deps.client.EXPECT().GroupsBan(gomock.Any(), banParams).Return(0, errors.New("connection reset")),
+		deps.client.EXPECT().GroupsBan(gomock.Any(), banParams).Return(1, nil),
+	)
+	gomock.InOrder(
+		deps.client.EXPECT().WallDeleteComment(gomock.Any(), deleteParams).Return(0, &api.Error{Code: api.ErrServer}),
+		deps.client.EXPECT().WallDeleteComment(gomock.Any(), deleteParams).Return(1, nil),
+	)
+
+	o := newOutbox(t)
+	s := NewService(zap.NewNop(), deps.client, nil, o, newAllowlist(t), newAudit(t, ""), heuristicRules, Config{RetryInterval: time.Millisecond})
+
+	got, err := s.CheckComment(context.Background(), comment)
+	if err == nil {
+		t.Errorf("CheckComment() error = %v, wantErr %v", err, true)
+	}
+	if got.Reason != entity.BanReasonPersonNonGrata {
+		t.Errorf("CheckComment() got = %v, want %v", got.Reason, entity.BanReasonPersonNonGrata)
+	}
+	if o.Len() != 2 {
+		t.Fatalf("outbox has %d actions, want 2", o.Len())
+	}
+
+	time.Sleep(5 * time.Millisecond)
+	s.processDueActions(context.Background())
+
+	if o.Len() != 0 {
+		t.Errorf("outbox has %d actions, want 0", o.Len())
+	}
+}
+
+func TestServiceCheckComment_NonRetryableFailure(t *testing.T) {
+	t.Parallel()
+
+	user := object.UsersUser{ID: 87524863, FirstName: "Bob", LastName: "Marley"}
+	comment := &entity.Comment{ID: 1, FromID: 87524863, OwnerID: -61061413}
+	heuristicRules := entity.HeuristicRules{
+		PersonNonGrata: []entity.HeuristicPersonNonGrataRule{
+			{Name: toPtr("Bob Marley")},
+		},
+	}
+
+	ctrl := gomock.NewController(t)
+	deps := dependencies{client: NewMockVkClient(ctrl)}
+	deps.client.EXPECT().
+		UsersGet(gomock.Any(), gomock.Any()).
+		Return([]object.UsersUser{user}, nil)
+	deps.client.EXPECT().GroupsBan(gomock.Any(), gomock.Any()).Return(0, &api.Error{Code: api.ErrPermission})
+	deps.client.EXPECT().WallDeleteComment(gomock.Any(), gomock.Any()).Return(1, nil)
+
+	o := newOutbox(t)
+	s := NewService(zap.NewNop(), deps.client, nil, o, newAllowlist(t), newAudit(t, ""), heuristicRules, Config{})
+
+	if _, err := s.CheckComment(context.Background(), comment); err == nil {
+		t.Errorf("CheckComment() error = %v, wantErr %v", err, true)
+	}
+	if o.Len() != 0 {
+		t.Errorf("outbox has %d actions, want 0", o.Len())
+	}
+}
+
+// failingOutbox is an outbox which can't store actions.
+type failingOutbox struct {
+	*outbox.Outbox
+}
+
+func (failingOutbox) Put(...entity.Action) error {
+	return errors.New("disk is full")
+}
+
+func TestServiceCheckComment_OutboxFailure(t *testing.T) {
+	t.Parallel()
+
+	user := object.UsersUser{ID: 87524863, FirstName: "Bob", LastName: "Marley"}
+	comment := &entity.Comment{ID: 1, FromID: 87524863, OwnerID: -61061413}
+	heuristicRules := entity.HeuristicRules{
+		PersonNonGrata: []entity.HeuristicPersonNonGrataRule{
+			{Name: toPtr("Bob Marley")},
+		},
+	}
+
+	ctrl := gomock.NewController(t)
+	deps := dependencies{client: NewMockVkClient(ctrl)}
+	deps.client.EXPECT().
+		UsersGet(gomock.Any(), gomock.Any()).
+		Return([]object.UsersUser{user}, nil)
+	deps.client.EXPECT().GroupsBan(gomock.Any(), gomock.Any()).Return(1, nil)
+	deps.client.EXPECT().WallDeleteComment(gomock.Any(), gomock.Any()).Return(1, nil)
+
+	o := failingOutbox{Outbox: newOutbox(t)}
+	s := NewService(zap.NewNop(), deps.client, nil, o, newAllowlist(t), newAudit(t, ""), heuristicRules, Config{})
+
+	got, err := s.CheckComment(context.Background(), comment)
+	if err != nil {
+		t.Errorf("CheckComment() error = %v, wantErr %v", err, false)
+	}
+	if len(got.Actions) != 2 {
+		t.Errorf("CheckComment() got %d actions, want 2", len(got.Actions))
+	}
+}
+
+func TestServiceCheckComment_AlreadyDone(t *testing.T) {
+	t.Parallel()
+
+	user := object.UsersUser{ID: 87524863, FirstName: "Bob", LastName: "Marley"}
+	comment := &entity.Comment{ID: 1, FromID: 87524863, OwnerID: -61061413}
+	heuristicRules := entity.HeuristicRules{
+		PersonNonGrata: []entity.HeuristicPersonNonGrataRule{
+			{Name: toPtr("Bob Marley")},
+		},
+	}
+
+	ctrl := gomock.NewController(t)
+	deps := dependencies{client: NewMockVkClient(ctrl)}
+	deps.client.EXPECT().
+		UsersGet(gomock.Any(), gomock.Any()).
+		Return([]object.UsersUser{user}, nil)
+	deps.client.EXPECT().
+		GroupsBan(gomock.Any(), gomock.Any()).
+		Return(0, &api.Error{Code: api.ErrParam, Message: "user is already banned"})
+	deps.client.EXPECT().
+		WallDeleteComment(gomock.Any(), gomock.Any()).
+		Return(0, &api.Error{Code: api.ErrNotFound})
+
+	o := newOutbox(t)
+	s := NewService(zap.NewNop(), deps.client, nil, o, newAllowlist(t), newAudit(t, ""), heuristicRules, Config{})
+
+	got, err := s.CheckComment(context.Background(), comment)
+	if err != nil {
+		t.Errorf("CheckComment() error = %v, wantErr %v", err, false)
+	}
+	if got.Reason != entity.BanReasonPersonNonGrata {
+		t.Errorf("CheckComment() got = %v, want %v", got.Reason, entity.BanReasonPersonNonGrata)
+	}
+	if o.Len() != 0 {
+		t.Errorf("outbox has %d actions, want 0", o.Len())
+	}
+}
+
+func TestServiceCheckComment_Community(t *testing.T) {
+	t.Parallel()
+
+	spamGroup := object.GroupsGroup{ID: 1234, Name: "Spam", ScreenName: "spam_club", MembersCount: 5}
+	heuristicRules := entity.HeuristicRules{
+		Community: []entity.HeuristicCommunityRule{
+			{MaxMembersCount: toPtr(10)},
+		},
+		Mention: []entity.HeuristicMentionRule{
+			{MaxCommunities: toPtr(1)},
+		},
+		ExemptCommunities: []int{4321},
+	}
+
+	tests := []struct {
+		name    string
+		comment *entity.Comment
+		setup   func(*dependencies)
+		want    entity.BanReason
+	}{
+		{
+			name:    "community banned",
+			comment: &entity.Comment{ID: 1, FromID: -1234, OwnerID: -61061413},
+			setup: func(d *dependencies) {
+				d.client.EXPECT().
+					GroupsGetByID(gomock.Any(), api.Params{"group_ids": 1234, "fields": "verified,members_count"}).
+					Return(api.GroupsGetByIDResponse{spamGroup}, nil)
+
+				d.client.EXPECT().GroupsBan(gomock.Any(), api.Params{
+					"group_id":        61061413,
+					"owner_id":        -1234,
+					"comment":         string(entity.BanReasonCommunity),
+					"comment_visible": 0,
+				}).Return(1, nil)
+
+				d.client.EXPECT().WallDeleteComment(gomock.Any(), api.Params{
+					"owner_id":   -61061413,
+					"comment_id": 1,
+				}).Return(1, nil)
+			},
+			want: entity.BanReasonCommunity,
+		},
+		{
+			name:    "exempt community",
+			comment: &entity.Comment{ID: 1, FromID: -4321, OwnerID: -61061413, Text: "[club1|a] [club2|b]"},
+			want:    entity.BanReasonNone,
+		},
+		{
+			name:    "comment on own wall",
+			comment: &entity.Comment{ID: 1, FromID: -61061413, OwnerID: -61061413},
+			want:    entity.BanReasonNone,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctrl := gomock.NewController(t)
+			deps := dependencies{client: NewMockVkClient(ctrl)}
+			if tt.setup != nil {
+				tt.setup(&deps)
+			}
+
+			s := NewService(zap.NewNop(), deps.client, nil, newOutbox(t), newAllowlist(t), newAudit(t, ""), heuristicRules, Config{})
+			got, err := s.CheckComment(context.Background(), tt.comment)
+			if err != nil {
+				t.Errorf("CheckComment() error = %v, wantErr %v", err, false)
+				return
+			}
+			if got.Reason != tt.want {
+				t.Errorf("CheckComment() got = %v, want %v", got.Reason, tt.want)
+			}
+		})
+	}
+}
+
+func TestServiceCheckComment_SkippedCommunity(t *testing.T) {
+	t.Parallel()
+
+	heuristicRules := entity.HeuristicRules{
+		User: []entity.HeuristicUserRule{
+			{ID: toPtr(-4321)},
+		},
+		Flood: []entity.HeuristicFloodRule{
+			{MaxComments: 1, Window: time.Minute},
+		},
+		CopyPaste: []entity.HeuristicCopyPasteRule{
+			{MinAuthors: 2, Window: time.Minute},
+		},
+		ExemptCommunities: []int{4321},
+	}
+
+	tests := []struct {
+		name        string
+		fromID      int
+		wantSkipped string
+	}{
+		{name: "community floods own wall", fromID: -61061413, wantSkipped: skippedOwnWall},
+		{name: "blacklisted exempt community", fromID: -4321, wantSkipped: skippedExemptCommunity},
 	}
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			// No VK API calls are expected.
+			ctrl := gomock.NewController(t)
+			deps := dependencies{client: NewMockVkClient(ctrl)}
+
+			s := NewService(zap.NewNop(), deps.client, nil, newOutbox(t), newAllowlist(t), newAudit(t, ""), heuristicRules, Config{})
+			for i := 1; i <= 3; i++ {
+				comment := &entity.Comment{ID: i, FromID: tt.fromID, OwnerID: -61061413, PostID: 1, Text: "Subscribe to us"}
+				got, err := s.CheckComment(context.Background(), comment)
+				if err != nil {
+					t.Errorf("CheckComment() error = %v, wantErr %v", err, false)
+				}
+				if got.Reason != entity.BanReasonNone || got.Skipped != tt.wantSkipped {
+					t.Errorf("CheckComment() got = %v, skipped %q, want %v, skipped %q", got.Reason, got.Skipped, entity.BanReasonNone, tt.wantSkipped)
+				}
+			}
+		})
+	}
+}
+
+func TestServiceCheckComment_Mentions(t *testing.T) {
+	t.Parallel()
+
+	user := object.UsersUser{ID: 87524863, FirstName: "Bob", LastName: "Marley"}
+	comment := &entity.Comment{
+		ID:      1,
+		FromID:  87524863,
+		OwnerID: -61061413,
+		Text:    "[id1|Pavel], [id2|Ivan], @id3 (Anna) and @durov, look at [club5|this]",
+	}
+	heuristicRules := entity.HeuristicRules{
+		Mention: []entity.HeuristicMentionRule{
+			{MaxUsers: toPtr(2)},
+		},
+	}
+
+	ctrl := gomock.NewController(t)
+	deps := dependencies{client: NewMockVkClient(ctrl)}
+	deps.client.EXPECT().
+		UsersGet(gomock.Any(), gomock.Any()).
+		Return([]object.UsersUser{user}, nil)
+	deps.client.EXPECT().GroupsBan(gomock.Any(), api.Params{
+		"group_id":        61061413,
+		"owner_id":        87524863,
+		"comment":         string(entity.BanReasonMentions),
+		"comment_visible": 0,
+	}).Return(1, nil)
+	deps.client.EXPECT().WallDeleteComment(gomock.Any(), gomock.Any()).Return(1, nil)
+
+	s := NewService(zap.NewNop(), deps.client, nil, newOutbox(t), newAllowlist(t), newAudit(t, ""), heuristicRules, Config{})
+
+	got, err := s.CheckComment(context.Background(), comment)
+	if err != nil {
+		t.Errorf("CheckComment() error = %v, wantErr %v", err, false)
+	}
+	if got.Reason != entity.BanReasonMentions {
+		t.Errorf("CheckComment() got = %v, want %v", got.Reason, entity.BanReasonMentions)
+	}
+}
+
+func TestServiceCheckComment_FuzzyName(t *testing.T) {
+	t.Parallel()
+
+	user := object.UsersUser{ID: 87524863, FirstName: "Serge1", LastName: "Ivanov"}
+	comment := &entity.Comment{ID: 1, FromID: 87524863, OwnerID: -61061413}
+	heuristicRules := entity.HeuristicRules{
+		PersonNonGrata: []entity.HeuristicPersonNonGrataRule{
+			{Name: toPtr("Сергей Иванов"), NameSimilarity: toPtr(0.9), FuzzyAction: entity.PunishmentDelete},
+		},
+	}
+
+	ctrl := gomock.NewController(t)
+	deps := dependencies{client: NewMockVkClient(ctrl)}
+	deps.client.EXPECT().
+		UsersGet(gomock.Any(), gomock.Any()).
+		Return([]object.UsersUser{user}, nil)
+	// Fuzzy match takes softer action, the comment is deleted without ban.
+	deps.client.EXPECT().WallDeleteComment(gomock.Any(), api.Params{
+		"owner_id":   -61061413,
+		"comment_id": 1,
+	}).Return(1, nil)
+
+	s := NewService(zap.NewNop(), deps.client, nil, newOutbox(t), newAllowlist(t), newAudit(t, ""), heuristicRules, Config{})
+
+	got, err := s.CheckComment(context.Background(), comment)
+	if err != nil {
+		t.Errorf("CheckComment() error = %v, wantErr %v", err, false)
+	}
+	if got.Reason != entity.BanReasonPersonNonGrataFuzzy {
+		t.Errorf("CheckComment() got = %v, want %v", got.Reason, entity.BanReasonPersonNonGrataFuzzy)
+	}
+}
+
+func TestServiceCheckComment_Blacklist(t *testing.T) {
+	t.Parallel()
+
+	heuristicRules := entity.HeuristicRules{
+		User: []entity.HeuristicUserRule{
+			{ID: toPtr(1)},
+			{ScreenName: toPtr("spammer")},
+			{ScreenName: toPtr("spam_club")},
+		},
+	}
+
+	ctrl := gomock.NewController(t)
+	deps := dependencies{client: NewMockVkClient(ctrl)}
+	deps.client.EXPECT().
+		UtilsResolveScreenName(gomock.Any(), api.Params{"screen_name": "spammer"}).
+		Return(api.UtilsResolveScreenNameResponse{ObjectID: 87524863, Type: "user"}, nil)
+	deps.client.EXPECT().
+		UtilsResolveScreenName(gomock.Any(), api.Params{"screen_name": "spam_club"}).
+		Return(api.UtilsResolveScreenNameResponse{ObjectID: 1234, Type: "group"}, nil)
+
+	s := NewService(zap.NewNop(), deps.client, nil, newOutbox(t), newAllowlist(t), newAudit(t, ""), heuristicRules, Config{})
+	if err := s.ResolveBlacklist(context.Background()); err != nil {
+		t.Fatalf("ResolveBlacklist() error = %v", err)
+	}
+
+	// Blacklisted authors are banned without users.get and groups.getById calls.
+	for _, fromID := range []int{1, 87524863, -1234} {
+		deps.client.EXPECT().GroupsBan(gomock.Any(), api.Params{
+			"group_id":        61061413,
+			"owner_id":        fromID,
+			"comment":         string(entity.BanReasonBlacklist),
+			"comment_visible": 0,
+		}).Return(1, nil)
+		deps.client.EXPECT().WallDeleteComment(gomock.Any(), gomock.Any()).Return(1, nil)
+
+		comment := &entity.Comment{ID: 1, FromID: fromID, OwnerID: -61061413}
+		got, err := s.CheckComment(context.Background(), comment)
+		if err != nil {
+			t.Errorf("CheckComment() error = %v, wantErr %v", err, false)
+		}
+		if got.Reason != entity.BanReasonBlacklist {
+			t.Errorf("CheckComment() got = %v, want %v", got.Reason, entity.BanReasonBlacklist)
+		}
+	}
+}
+
+func TestServiceCheckComment_Allowlist(t *testing.T) {
+	t.Parallel()
+
+	heuristicRules := entity.HeuristicRules{
+		User: []entity.HeuristicUserRule{
+			{ID: toPtr(1)},
+			{ID: toPtr(2)},
+		},
+	}
+
+	ctrl := gomock.NewController(t)
+	deps := dependencies{client: NewMockVkClient(ctrl)}
+	deps.client.EXPECT().
+		GroupsGetMembersFilterManagers(gomock.Any(), api.Params{"group_id": 61061413, "offset": 0, "count": 1000}).
+		Return(api.GroupsGetMembersFilterManagersResponse{
+			Count: 1,
+			Items: []object.GroupsMemberRoleXtrUsersUser{{UsersUser: object.UsersUser{ID: 2}, Role: "moderator"}},
+		}, nil)
+
+	s := NewService(zap.NewNop(), deps.client, nil, newOutbox(t), newAllowlist(t, 1), newAudit(t, ""), heuristicRules, Config{GroupID: 61061413})
+	if err := s.refreshAllowlist(context.Background()); err != nil {
+		t.Fatalf("refreshAllowlist() error = %v", err)
+	}
+
+	// Explicitly trusted user and community manager are not banned even if blacklisted.
+	for _, fromID := range []int{1, 2} {
+		comment := &entity.Comment{ID: 1, FromID: fromID, OwnerID: -61061413}
+		got, err := s.CheckComment(context.Background(), comment)
+		if err != nil {
+			t.Errorf("CheckComment() error = %v, wantErr %v", err, false)
+		}
+		if got.Reason != entity.BanReasonNone {
+			t.Errorf("CheckComment() got = %v, want %v", got.Reason, entity.BanReasonNone)
+		}
+	}
+}
+
+func TestServiceCheckComment_Flood(t *testing.T) {
+	t.Parallel()
+
+	heuristicRules := entity.HeuristicRules{
+		Flood: []entity.HeuristicFloodRule{
+			{MaxComments: 2, Window: time.Minute, PerPost: true, Action: entity.PunishmentDelete},
+		},
+	}
+
+	ctrl := gomock.NewController(t)
+	deps := dependencies{client: NewMockVkClient(ctrl)}
+	deps.client.EXPECT().
+		UsersGet(gomock.Any(), api.Params{"user_ids": 87524863, "fields": "bdate"}).
+		Return([]object.UsersUser{{ID: 87524863, FirstName: "Bob", LastName: "Marley"}}, nil).
+		Times(1)
+	deps.client.EXPECT().WallDeleteComment(gomock.Any(), api.Params{
+		"owner_id":   -61061413,
+		"comment_id": 4,
+	}).Return(1, nil)
+
+	s := NewService(zap.NewNop(), deps.client, nil, newOutbox(t), newAllowlist(t), newAudit(t, ""), heuristicRules, Config{})
+
+	tests := []struct {
+		postID int
+		want   entity.BanReason
+	}{
+		{postID: 1, want: entity.BanReasonNone},
+		{postID: 1, want: entity.BanReasonNone},
+		// Comments are counted per post.
+		{postID: 2, want: entity.BanReasonNone},
+		{postID: 1, want: entity.BanReasonFlood},
+	}
+	for i, tt := range tests {
+		comment := &entity.Comment{ID: i + 1, FromID: 87524863, OwnerID: -61061413, PostID: tt.postID}
+		got, err := s.CheckComment(context.Background(), comment)
+		if err != nil {
+			t.Errorf("CheckComment() error = %v, wantErr %v", err, false)
+		}
+		if got.Reason != tt.want {
+			t.Errorf("CheckComment() comment %d got = %v, want %v", comment.ID, got.Reason, tt.want)
+		}
+	}
+}
+
+func TestServiceCheckComment_CopyPaste(t *testing.T) {
+	t.Parallel()
+
+	heuristicRules := entity.HeuristicRules{
+		CopyPaste: []entity.HeuristicCopyPasteRule{
+			{MinAuthors: 3, Window: time.Minute, Cleanup: true},
+		},
+	}
+
+	ctrl := gomock.NewController(t)
+	deps := dependencies{client: NewMockVkClient(ctrl)}
+	for _, id := range []int{1, 2} {
+		deps.client.EXPECT().
+			UsersGet(gomock.Any(), api.Params{"user_ids": id, "fields": "bdate"}).
+			Return([]object.UsersUser{{ID: id, FirstName: "Bob", LastName: "Marley"}}, nil)
+	}
+	// Earlier copies are deleted, the last author is banned.
+	for _, id := range []int{1, 2, 3} {
+		deps.client.EXPECT().WallDeleteComment(gomock.Any(), api.Params{
+			"owner_id":   -61061413,
+			"comment_id": id,
+		}).Return(1, nil)
+	}
+	deps.client.EXPECT().GroupsBan(gomock.Any(), api.Params{
+		"group_id":        61061413,
+		"owner_id":        3,
+		"comment":         string(entity.BanReasonCopyPaste),
+		"comment_visible": 0,
+	}).Return(1, nil)
+
+	s := NewService(zap.NewNop(), deps.client, nil, newOutbox(t), newAllowlist(t), newAudit(t, ""), heuristicRules, Config{})
+
+	want := []entity.BanReason{entity.BanReasonNone, entity.BanReasonNone, entity.BanReasonCopyPaste}
+	for i, w := range want {
+		comment := &entity.Comment{
+			ID:      i + 1,
+			FromID:  i + 1,
+			OwnerID: -61061413,
+			Text:    "Заработок от 5000 рублей в день без вложений, пиши в личку",
+		}
+		got, err := s.CheckComment(context.Background(), comment)
+		if err != nil {
+			t.Errorf("CheckComment() error = %v, wantErr %v", err, false)
+		}
+		if got.Reason != w {
+			t.Errorf("CheckComment() comment %d got = %v, want %v", comment.ID, got.Reason, w)
+		}
+	}
+}
+
+func TestServiceCheckComment_Links(t *testing.T) {
+	t.Parallel()
+
+	heuristicRules := entity.HeuristicRules{
+		Link: []entity.HeuristicLinkRule{
+			{DenyDomains: []string{"*.scam.ru"}},
+			{AllowDomains: []string{"vk.com"}, MaxAccountAge: 24 * time.Hour, Action: entity.PunishmentDelete},
+		},
+	}
+
+	ctrl := gomock.NewController(t)
+	deps := dependencies{client: NewMockVkClient(ctrl), registrationDates: NewMockRegistrationDates(ctrl)}
+	for _, id := range []int{1, 2, 3, 4} {
+		deps.client.EXPECT().
+			UsersGet(gomock.Any(), api.Params{"user_ids": id, "fields": "bdate"}).
+			Return([]object.UsersUser{{ID: id, FirstName: "Bob", LastName: "Marley"}}, nil)
+	}
+	// Short link is resolved once.
+	deps.client.EXPECT().
+		UtilsCheckLink(gomock.Any(), api.Params{"url": "https://vk.cc/abc"}).
+		Return(api.UtilsCheckLinkResponse{Link: "https://promo.scam.ru/win", Status: "not_banned"}, nil).
+		Times(1)
+	deps.registrationDates.EXPECT().
+		RegistrationDate(gomock.Any(), 2).
+		Return(time.Now().Add(-time.Hour), nil)
+	deps.registrationDates.EXPECT().
+		RegistrationDate(gomock.Any(), 3).
+		Return(time.Date(2010, 1, 1, 0, 0, 0, 0, time.UTC), nil)
+	deps.registrationDates.EXPECT().
+		RegistrationDate(gomock.Any(), 4).
+		Return(time.Time{}, ErrBadResponse)
+	deps.client.EXPECT().GroupsBan(gomock.Any(), gomock.Any()).Return(1, nil)
+	deps.client.EXPECT().WallDeleteComment(gomock.Any(), gomock.Any()).Return(1, nil).Times(2)
+
+	s := NewService(zap.NewNop(), deps.client, deps.registrationDates, newOutbox(t), newAllowlist(t), newAudit(t, ""), heuristicRules, Config{})
+
+	tests := []struct {
+		fromID  int
+		text    string
+		want    entity.BanReason
+		wantAge string
+	}{
+		{fromID: 1, text: "Выиграй iPhone: https://vk.cc/abc", want: entity.BanReasonLink},
+		{fromID: 2, text: "Подробнее на example.com", want: entity.BanReasonLink},
+		// Allowed links do not need registration date.
+		{fromID: 2, text: "Мой профиль vk.com/id2", want: entity.BanReasonNone},
+		{fromID: 3, text: "Подробнее на example.com", want: entity.BanReasonNone, wantAge: "account_age"},
+		// Failed lookup is recorded in the decision.
+		{fromID: 4, text: "Подробнее на example.com", want: entity.BanReasonNone, wantAge: "account_age_error"},
+		// Failed lookup is cached for a while.
+		{fromID: 4, text: "Подробнее на example.com", want: entity.BanReasonNone, wantAge: "account_age_error"},
+	}
+	for i, tt := range tests {
+		comment := &entity.Comment{ID: i + 1, FromID: tt.fromID, OwnerID: -61061413, Text: tt.text}
+		got, err := s.CheckComment(context.Background(), comment)
+		if err != nil {
+			t.Errorf("CheckComment() error = %v, wantErr %v", err, false)
+		}
+		if got.Reason != tt.want {
+			t.Errorf("CheckComment() comment %d got = %v, want %v", comment.ID, got.Reason, tt.want)
+		}
+		if tt.wantAge != "" {
+			last := got.Rules[len(got.Rules)-1]
+			if _, exists := last.Values[tt.wantAge]; !exists {
+				t.Errorf("CheckComment() comment %d rule %s values = %v, want %s", comment.ID, last.Rule, last.Values, tt.wantAge)
+			}
+		}
+	}
+}
+
+func TestServiceCheckComment_Profanity(t *testing.T) {
+	t.Parallel()
+
+	heuristicRules := entity.HeuristicRules{
+		Profanity: []entity.HeuristicProfanityRule{{}},
+	}
+
+	ctrl := gomock.NewController(t)
+	deps := dependencies{client: NewMockVkClient(ctrl)}
+	deps.client.EXPECT().
+		UsersGet(gomock.Any(), api.Params{"user_ids": 87524863, "fields": "bdate"}).
+		Return([]object.UsersUser{{ID: 87524863, FirstName: "Bob", LastName: "Marley"}}, nil)
+	// Profanity is banned with VK "strong language" reason.
+	deps.client.EXPECT().GroupsBan(gomock.Any(), api.Params{
+		"group_id":        61061413,
+		"owner_id":        87524863,
+		"comment":         string(entity.BanReasonProfanity),
+		"comment_visible": 0,
+		"reason":          3,
+	}).Return(1, nil)
+	deps.client.EXPECT().WallDeleteComment(gomock.Any(), gomock.Any()).Return(1, nil)
+
+	s := NewService(zap.NewNop(), deps.client, nil, newOutbox(t), newAllowlist(t), newAudit(t, ""), heuristicRules, Config{})
+
+	comment := &entity.Comment{ID: 1, FromID: 87524863, OwnerID: -61061413, Text: "Ну ты и дeбил"}
+	got, err := s.CheckComment(context.Background(), comment)
+	if err != nil {
+		t.Errorf("CheckComment() error = %v, wantErr %v", err, false)
+	}
+	if got.Reason != entity.BanReasonProfanity {
+		t.Errorf("CheckComment() got = %v, want %v", got.Reason, entity.BanReasonProfanity)
+	}
+}
+
+func TestServiceCheckComment_SpamPattern(t *testing.T) {
+	t.Parallel()
+
+	heuristicRules := entity.HeuristicRules{
+		SpamPattern: []entity.HeuristicSpamPatternRule{{Kinds: []string{"phone", "telegram"}}},
+	}
+
+	ctrl := gomock.NewController(t)
+	deps := dependencies{client: NewMockVkClient(ctrl)}
+	deps.client.EXPECT().
+		UsersGet(gomock.Any(), api.Params{"user_ids": 87524863, "fields": "bdate"}).
+		Return([]object.UsersUser{{ID: 87524863, FirstName: "Bob", LastName: "Marley"}}, nil)
+	// Spam patterns are banned with VK "spam" reason.
+	deps.client.EXPECT().GroupsBan(gomock.Any(), api.Params{
+		"group_id":        61061413,
+		"owner_id":        87524863,
+		"comment":         string(entity.BanReasonSpamPattern),
+		"comment_visible": 0,
+		"reason":          1,
+	}).Return(1, nil)
+	deps.client.EXPECT().WallDeleteComment(gomock.Any(), gomock.Any()).Return(1, nil)
+
+	s := NewService(zap.NewNop(), deps.client, nil, newOutbox(t), newAllowlist(t), newAudit(t, ""), heuristicRules, Config{})
+
+	comment := &entity.Comment{ID: 1, FromID: 87524863, OwnerID: -61061413, Text: "Заработок от 5000 в день, звони +7 9О9 123 45 67"}
+	got, err := s.CheckComment(context.Background(), comment)
+	if err != nil {
+		t.Errorf("CheckComment() error = %v, wantErr %v", err, false)
+	}
+	if got.Reason != entity.BanReasonSpamPattern {
+		t.Errorf("CheckComment() got = %v, want %v", got.Reason, entity.BanReasonSpamPattern)
+	}
+}
+
+func TestServiceCheckComment_ExpressionHistory(t *testing.T) {
+	t.Parallel()
+
+	heuristicRules := entity.HeuristicRules{
+		Expression: []entity.HeuristicExpressionRule{
+			{Expression: `history.punished >= 1`},
+			{Expression: `comment.text contains "казино"`, Action: entity.PunishmentDelete},
+		},
+	}
+	if err := heuristicRules.CompileExpressions(); err != nil {
+		t.Fatalf("CompileExpressions() error = %v", err)
+	}
+
+	ctrl := gomock.NewController(t)
+	deps := dependencies{client: NewMockVkClient(ctrl)}
+	deps.client.EXPECT().
+		UsersGet(gomock.Any(), api.Params{"user_ids": 87524863, "fields": "bdate"}).
+		Return([]object.UsersUser{{ID: 87524863, FirstName: "Bob", LastName: "Marley"}}, nil)
+	deps.client.EXPECT().WallDeleteComment(gomock.Any(), gomock.Any()).Return(1, nil).Times(2)
+	// The second comment is banned because the first one was deleted.
+	deps.client.EXPECT().GroupsBan(gomock.Any(), api.Params{
+		"group_id":        61061413,
+		"owner_id":        87524863,
+		"comment":         string(entity.BanReasonExpression),
+		"comment_visible": 0,
+	}).Return(1, nil)
+
+	s := NewService(zap.NewNop(), deps.client, nil, newOutbox(t), newAllowlist(t), newAudit(t, ""), heuristicRules, Config{})
+
+	for i, text := range []string{"Лучшее казино", "Привет"} {
+		comment := &entity.Comment{ID: i + 1, FromID: 87524863, OwnerID: -61061413, Text: text}
+		got, err := s.CheckComment(context.Background(), comment)
+		if err != nil {
+			t.Errorf("CheckComment() error = %v, wantErr %v", err, false)
+		}
+		if got.Reason != entity.BanReasonExpression {
+			t.Errorf("CheckComment() got = %v, want %v", got.Reason, entity.BanReasonExpression)
+		}
+	}
+}
+
+func TestServiceCheckComment_Decision(t *testing.T) {
+	t.Parallel()
+
+	heuristicRules := entity.HeuristicRules{
+		PersonNonGrata: []entity.HeuristicPersonNonGrataRule{
+			{Name: toPtr("Pavel Durov")},
+			{Name: toPtr("Bob Marley")},
+		},
+	}
+
+	ctrl := gomock.NewController(t)
+	deps := dependencies{client: NewMockVkClient(ctrl)}
+	deps.client.EXPECT().
+		UsersGet(gomock.Any(), api.Params{"user_ids": 87524863, "fields": "bdate"}).
+		Return([]object.UsersUser{{ID: 87524863, FirstName: "Bob", LastName: "Marley"}}, nil)
+	deps.client.EXPECT().GroupsBan(gomock.Any(), gomock.Any()).Return(1, nil)
+	deps.client.EXPECT().WallDeleteComment(gomock.Any(), gomock.Any()).Return(0, errors.New("some error"))
+
+	auditPath := filepath.Join(t.TempDir(), "audit.jsonl")
+	s := NewService(zap.NewNop(), deps.client, nil, newOutbox(t), newAllowlist(t), newAudit(t, auditPath), heuristicRules, Config{})
+
+	comment := &entity.Comment{ID: 1, FromID: 87524863, OwnerID: -61061413, Text: "test"}
+	got, err := s.CheckComment(context.Background(), comment)
+	if err == nil {
+		t.Errorf("CheckComment() error = %v, wantErr %v", err, true)
+	}
+
+	want := entity.Decision{
+		CommentID:  1,
+		FromID:     87524863,
+		OwnerID:    -61061413,
+		Reason:     entity.BanReasonPersonNonGrata,
+		Punishment: entity.PunishmentBan,
+		Score:      1,
+		Rules: []entity.RuleResult{
+			{Rule: "person_non_grata[0]", Matched: false},
+			{Rule: "person_non_grata[1]", Matched: true, Score: 1},
+		},
+		Actions: []entity.ActionResult{
+			{Type: entity.ActionTypeBan, OwnerID: -61061413, UserID: 87524863},
+			{Type: entity.ActionTypeDeleteComment, OwnerID: -61061413, CommentID: 1, Error: "failed to delete comment: some error"},
+		},
+	}
+	// Values are checked in entity tests.
+	for i := range got.Rules {
+		got.Rules[i].Values = nil
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("CheckComment() got = %+v, want %+v", got, want)
+	}
+
+	data, err := os.ReadFile(auditPath)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	if lines := strings.Count(string(data), "\n"); lines != 1 || !strings.Contains(string(data), `"reason":"person_non_grata"`) {
+		t.Errorf("audit log = %s, want one person_non_grata decision", data)
+	}
+}
+
+func TestServiceExplainComment(t *testing.T) {
+	t.Parallel()
+
+	heuristicRules := entity.HeuristicRules{
+		User: []entity.HeuristicUserRule{{ID: toPtr(1)}},
+		Flood: []entity.HeuristicFloodRule{
+			{MaxComments: 1, Window: time.Minute},
+		},
+	}
+
+	// Nothing is punished, so only users are requested.
+	ctrl := gomock.NewController(t)
+	deps := dependencies{client: NewMockVkClient(ctrl)}
+	deps.client.EXPECT().
+		UsersGet(gomock.Any(), api.Params{"user_ids": 87524863, "fields": "bdate"}).
+		Return([]object.UsersUser{{ID: 87524863, FirstName: "Bob", LastName: "Marley"}}, nil)
+
+	s := NewService(zap.NewNop(), deps.client, nil, newOutbox(t), newAllowlist(t), newAudit(t, ""), heuristicRules, Config{})
+
+	got, err := s.ExplainComment(context.Background(), &entity.Comment{ID: 1, FromID: 1, OwnerID: -61061413})
+	if err != nil {
+		t.Errorf("ExplainComment() error = %v, wantErr %v", err, false)
+	}
+	if got.Reason != entity.BanReasonBlacklist || !got.DryRun || len(got.Actions) != 0 {
+		t.Errorf("ExplainComment() got = %+v, want dry run blacklist without actions", got)
+	}
+
+	// Explained comments are not counted by flood rules.
+	for i := 1; i <= 3; i++ {
+		got, err = s.ExplainComment(context.Background(), &entity.Comment{ID: i, FromID: 87524863, OwnerID: -61061413})
+		if err != nil {
+			t.Errorf("ExplainComment() error = %v, wantErr %v", err, false)
+		}
+		if got.Reason != entity.BanReasonNone {
+			t.Errorf("ExplainComment() got = %v, want %v", got.Reason, entity.BanReasonNone)
+		}
+	}
+
+	got, err = s.CheckComment(context.Background(), &entity.Comment{ID: 4, FromID: 87524863, OwnerID: -61061413})
+	if err != nil {
+		t.Errorf("CheckComment() error = %v, wantErr %v", err, false)
+	}
+	if got.Reason != entity.BanReasonNone {
+		t.Errorf("CheckComment() got = %v, want %v", got.Reason, entity.BanReasonNone)
+	}
+
+	// The next comment would be flood.
+	got, err = s.ExplainComment(context.Background(), &entity.Comment{ID: 5, FromID: 87524863, OwnerID: -61061413})
+	if err != nil {
+		t.Errorf("ExplainComment() error = %v, wantErr %v", err, false)
+	}
+	if got.Reason != entity.BanReasonFlood || len(got.Actions) != 0 {
+		t.Errorf("ExplainComment() got = %+v, want flood without actions", got)
+	}
+	if h := s.commentHistory(87524863); h.Comments != 1 || h.Punished != 0 {
+		t.Errorf("commentHistory() = %+v, want 1 comment", h)
+	}
+}
+
+func TestServiceReloadRules(t *testing.T) {
+	t.Parallel()
+
+	heuristicRules := entity.HeuristicRules{
+		User: []entity.HeuristicUserRule{{ID: toPtr(1)}},
+	}
+
+	ctrl := gomock.NewController(t)
+	deps := dependencies{client: NewMockVkClient(ctrl)}
+	s := NewService(zap.NewNop(), deps.client, nil, newOutbox(t), newAllowlist(t), newAudit(t, ""), heuristicRules, Config{})
+
+	// Rules are kept when blacklisted screen name fails to resolve.
+	deps.client.EXPECT().
+		UtilsResolveScreenName(gomock.Any(), api.Params{"screen_name": "spammer"}).
+		Return(api.UtilsResolveScreenNameResponse{}, nil)
+	err := s.ReloadRules(context.Background(), entity.HeuristicRules{
+		User: []entity.HeuristicUserRule{{ScreenName: toPtr("spammer")}},
+	})
+	if !errors.Is(err, ErrScreenNameNotFound) {
+		t.Errorf("ReloadRules() error = %v, want %v", err, ErrScreenNameNotFound)
+	}
+
+	deps.client.EXPECT().
+		UtilsResolveScreenName(gomock.Any(), api.Params{"screen_name": "spammer"}).
+		Return(api.UtilsResolveScreenNameResponse{ObjectID: 87524863, Type: "user"}, nil)
+	err = s.ReloadRules(context.Background(), entity.HeuristicRules{
+		User: []entity.HeuristicUserRule{{ScreenName: toPtr("spammer")}},
+	})
+	if err != nil {
+		t.Fatalf("ReloadRules() error = %v", err)
+	}
+
+	// User blacklisted by the old rules is checked with the new ones.
+	deps.client.EXPECT().
+		UsersGet(gomock.Any(), api.Params{"user_ids": 1, "fields": "bdate"}).
+		Return([]object.UsersUser{{ID: 1, FirstName: "Pavel", LastName: "Durov"}}, nil)
+	got, err := s.CheckComment(context.Background(), &entity.Comment{ID: 1, FromID: 1, OwnerID: -61061413})
+	if err != nil {
+		t.Errorf("CheckComment() error = %v, wantErr %v", err, false)
+	}
+	if got.Reason != entity.BanReasonNone {
+		t.Errorf("CheckComment() got = %v, want %v", got.Reason, entity.BanReasonNone)
+	}
+
+	deps.client.EXPECT().GroupsBan(gomock.Any(), api.Params{
+		"group_id":        61061413,
+		"owner_id":        87524863,
+		"comment":         string(entity.BanReasonBlacklist),
+		"comment_visible": 0,
+	}).Return(1, nil)
+	deps.client.EXPECT().WallDeleteComment(gomock.Any(), gomock.Any()).Return(1, nil)
+	got, err = s.CheckComment(context.Background(), &entity.Comment{ID: 2, FromID: 87524863, OwnerID: -61061413})
+	if err != nil {
+		t.Errorf("CheckComment() error = %v, wantErr %v", err, false)
+	}
+	if got.Reason != entity.BanReasonBlacklist {
+		t.Errorf("CheckComment() got = %v, want %v", got.Reason, entity.BanReasonBlacklist)
+	}
+}
+
+func TestServiceReloadRules_UserFields(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	deps := dependencies{client: NewMockVkClient(ctrl)}
+	s := NewService(zap.NewNop(), deps.client, nil, newOutbox(t), newAllowlist(t), newAudit(t, ""), entity.HeuristicRules{}, Config{})
+
+	deps.client.EXPECT().
+		UsersGet(gomock.Any(), api.Params{"user_ids": 87524863, "fields": "bdate"}).
+		Return([]object.UsersUser{{ID: 87524863, FirstName: "Bob", LastName: "Marley"}}, nil)
+	if _, err := s.CheckComment(context.Background(), &entity.Comment{ID: 1, FromID: 87524863, OwnerID: -61061413}); err != nil {
+		t.Errorf("CheckComment() error = %v, wantErr %v", err, false)
+	}
+
+	err := s.ReloadRules(context.Background(), entity.HeuristicRules{
+		PersonNonGrata: []entity.HeuristicPersonNonGrataRule{{Name: toPtr("Bob Marley"), City: toPtr("Kingston")}},
+	})
+	if err != nil {
+		t.Fatalf("ReloadRules() error = %v", err)
+	}
+
+	// Cached user has no city, it is requested again with the new fields.
+	deps.client.EXPECT().
+		UsersGet(gomock.Any(), api.Params{"user_ids": 87524863, "fields": "bdate,city"}).
+		Return([]object.UsersUser{{
+			ID:        87524863,
+			FirstName: "Bob",
+			LastName:  "Marley",
+			City:      object.BaseObject{ID: 1, Title: "Kingston"},
+		}}, nil)
+	deps.client.EXPECT().GroupsBan(gomock.Any(), gomock.Any()).Return(1, nil)
+	deps.client.EXPECT().WallDeleteComment(gomock.Any(), gomock.Any()).Return(1, nil)
+	got, err := s.CheckComment(context.Background(), &entity.Comment{ID: 2, FromID: 87524863, OwnerID: -61061413})
+	if err != nil {
+		t.Errorf("CheckComment() error = %v, wantErr %v", err, false)
+	}
+	if got.Reason != entity.BanReasonPersonNonGrata {
+		t.Errorf("CheckComment() got = %v, want %v", got.Reason, entity.BanReasonPersonNonGrata)
+	}
+}
+
+func TestServiceReloadRules_Allowlist(t *testing.T) {
+	t.Parallel()
+
+	heuristicRules := entity.HeuristicRules{
+		User: []entity.HeuristicUserRule{{ID: toPtr(1)}},
+	}
+
+	ctrl := gomock.NewController(t)
+	deps := dependencies{client: NewMockVkClient(ctrl)}
+	s := NewService(zap.NewNop(), deps.client, nil, newOutbox(t), newAllowlist(t), newAudit(t, ""), heuristicRules, Config{})
+
+	heuristicRules.Allowlist = []int{1}
+	if err := s.ReloadRules(context.Background(), heuristicRules); err != nil {
+		t.Fatalf("ReloadRules() error = %v", err)
+	}
+
+	// Blacklisted user trusted by the new rules is not banned.
+	got, err := s.CheckComment(context.Background(), &entity.Comment{ID: 1, FromID: 1, OwnerID: -61061413})
+	if err != nil {
+		t.Errorf("CheckComment() error = %v, wantErr %v", err, false)
+	}
+	if got.Reason != entity.BanReasonNone || got.Skipped != skippedAllowlist {
+		t.Errorf("CheckComment() got = %v, skipped %q, want %v, skipped %q", got.Reason, got.Skipped, entity.BanReasonNone, skippedAllowlist)
+	}
+}
+
+func TestServiceReloadRules_ExemptCommunities(t *testing.T) {
+	t.Parallel()
+
+	heuristicRules := entity.HeuristicRules{
+		User: []entity.HeuristicUserRule{{ID: toPtr(-4321)}},
+	}
+
+	ctrl := gomock.NewController(t)
+	deps := dependencies{client: NewMockVkClient(ctrl)}
+	s := NewService(zap.NewNop(), deps.client, nil, newOutbox(t), newAllowlist(t), newAudit(t, ""), heuristicRules, Config{})
+
+	heuristicRules.ExemptCommunities = []int{4321}
+	if err := s.ReloadRules(context.Background(), heuristicRules); err != nil {
+		t.Fatalf("ReloadRules() error = %v", err)
+	}
+
+	// Blacklisted community exempt by the new rules is not banned.
+	got, err := s.CheckComment(context.Background(), &entity.Comment{ID: 1, FromID: -4321, OwnerID: -61061413})
+	if err != nil {
+		t.Errorf("CheckComment() error = %v, wantErr %v", err, false)
+	}
+	if got.Reason != entity.BanReasonNone || got.Skipped != skippedExemptCommunity {
+		t.Errorf("CheckComment() got = %v, skipped %q, want %v, skipped %q", got.Reason, got.Skipped, entity.BanReasonNone, skippedExemptCommunity)
+	}
+}
+
+func newAllowlist(t *testing.T, ids ...int) *allowlist.Allowlist {
+	t.Helper()
+
+	a, err := allowlist.New(ids, 0, "", false)
+	if err != nil {
+		t.Fatalf("allowlist.New() error = %v", err)
+	}
+
+	return a
+}
+
+func newAudit(t *testing.T, path string) *audit.Log {
+	t.Helper()
+
+	a, err := audit.New(path)
+	if err != nil {
+		t.Fatalf("audit.New() error = %v", err)
+	}
+	t.Cleanup(func() { _ = a.Close() })
+
+	return a
+}
+
+func newOutbox(t *testing.T) *outbox.Outbox {
+	t.Helper()
+
+	o, err := outbox.New("")
+	if err != nil {
+		t.Fatalf("outbox.New() error = %v", err)
+	}
+
+	return o
 }
 
 func toPtr[T any](v T) *T {
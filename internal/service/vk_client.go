@@ -0,0 +1,56 @@
+package service
+
+import (
+	"context"
+
+	"github.com/SevereCloud/vksdk/v2/api"
+)
+
+// vkClient is a VkClient backed by VK SDK.
+type vkClient struct {
+	vk *api.VK
+}
+
+// NewVkClient creates a new VK API client.
+// Context is passed to every request, so requests are canceled with it.
+func NewVkClient(vk *api.VK) VkClient {
+	return &vkClient{vk: vk}
+}
+
+func (c *vkClient) UsersGet(ctx context.Context, params api.Params) (api.UsersGetResponse, error) {
+	return c.vk.UsersGet(params.WithContext(ctx))
+}
+
+func (c *vkClient) UtilsResolveScreenName(
+	ctx context.Context,
+	params api.Params,
+) (api.UtilsResolveScreenNameResponse, error) {
+	return c.vk.UtilsResolveScreenName(params.WithContext(ctx))
+}
+
+func (c *vkClient) GroupsGetByID(ctx context.Context, params api.Params) (api.GroupsGetByIDResponse, error) {
+	return c.vk.GroupsGetByID(params.WithContext(ctx))
+}
+
+func (c *vkClient) GroupsGetMembers(ctx context.Context, params api.Params) (api.GroupsGetMembersResponse, error) {
+	return c.vk.GroupsGetMembers(params.WithContext(ctx))
+}
+
+func (c *vkClient) GroupsGetMembersFilterManagers(
+	ctx context.Context,
+	params api.Params,
+) (api.GroupsGetMembersFilterManagersResponse, error) {
+	return c.vk.GroupsGetMembersFilterManagers(params.WithContext(ctx))
+}
+
+func (c *vkClient) GroupsBan(ctx context.Context, params api.Params) (int, error) {
+	return c.vk.GroupsBan(params.WithContext(ctx))
+}
+
+func (c *vkClient) WallDeleteComment(ctx context.Context, params api.Params) (int, error) {
+	return c.vk.WallDeleteComment(params.WithContext(ctx))
+}
+
+func (c *vkClient) UtilsCheckLink(ctx context.Context, params api.Params) (api.UtilsCheckLinkResponse, error) {
+	return c.vk.UtilsCheckLink(params.WithContext(ctx))
+}
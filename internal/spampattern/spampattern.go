@@ -0,0 +1,235 @@
+package spampattern
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Kind is a kind of spam entity.
+type Kind string
+
+// Available kinds.
+const (
+	KindPhone    Kind = "phone"
+	KindCard     Kind = "card"
+	KindTelegram Kind = "telegram"
+	KindWhatsApp Kind = "whatsapp"
+	KindWallet   Kind = "wallet"
+)
+
+// Kinds contains all available kinds.
+var Kinds = []Kind{KindPhone, KindCard, KindTelegram, KindWhatsApp, KindWallet}
+
+// Entity is a spam entity found in text.
+type Entity struct {
+	Kind Kind
+	// Value is a normalized value, card numbers are masked.
+	Value string
+}
+
+func (e Entity) String() string {
+	return string(e.Kind) + ":" + e.Value
+}
+
+// Find returns spam entities found in text.
+func Find(text string) []Entity {
+	var entities []Entity
+	entities = append(entities, findTelegram(text)...)
+	entities = append(entities, findWhatsApp(text)...)
+	entities = append(entities, findWallets(text)...)
+	entities = append(entities, findNumbers(text)...)
+
+	return dedup(entities)
+}
+
+func dedup(entities []Entity) []Entity {
+	seen := make(map[Entity]struct{}, len(entities))
+	result := entities[:0]
+	for _, e := range entities {
+		if _, ok := seen[e]; ok {
+			continue
+		}
+		seen[e] = struct{}{}
+		result = append(result, e)
+	}
+
+	return result
+}
+
+var (
+	telegramLinkRe = regexp.MustCompile(`(?i)\b(?:t\.me|telegram\.(?:me|dog)|tg://resolve\?domain=)/?@?([a-z][a-z0-9_]{3,31})`)
+	telegramNameRe = regexp.MustCompile(`(?:^|[^\w])@([a-zA-Z][a-zA-Z0-9_]{3,31})`)
+	// telegramWordRe matches words announcing a Telegram handle, including spelled in Cyrillic.
+	telegramWordRe = regexp.MustCompile(`(?i)telegram|телеграм|телега|\btg\b|(?:^|[^\p{L}])тг(?:$|[^\p{L}])`)
+
+	whatsAppLinkRe = regexp.MustCompile(`(?i)(?:wa\.me/|api\.whatsapp\.com/send\?phone=)\+?(\d{10,15})|chat\.whatsapp\.com/([a-z0-9]+)`)
+	whatsAppWordRe = regexp.MustCompile(`(?i)whats\s?app|ват?с\s?ап|вотс\s?ап|вацап`)
+)
+
+// findTelegram returns Telegram handles from links and from "@name" announced as Telegram.
+// Without announcement "@name" is a VK mention.
+func findTelegram(text string) []Entity {
+	var entities []Entity
+	for _, m := range telegramLinkRe.FindAllStringSubmatch(text, -1) {
+		entities = append(entities, Entity{Kind: KindTelegram, Value: "@" + strings.ToLower(m[1])})
+	}
+	if telegramWordRe.MatchString(text) {
+		for _, m := range telegramNameRe.FindAllStringSubmatch(text, -1) {
+			entities = append(entities, Entity{Kind: KindTelegram, Value: "@" + strings.ToLower(m[1])})
+		}
+	}
+
+	return entities
+}
+
+// findWhatsApp returns WhatsApp links and phones announced as WhatsApp.
+func findWhatsApp(text string) []Entity {
+	var entities []Entity
+	for _, m := range whatsAppLinkRe.FindAllStringSubmatch(text, -1) {
+		if m[1] != "" {
+			entities = append(entities, Entity{Kind: KindWhatsApp, Value: "+" + m[1]})
+		} else {
+			entities = append(entities, Entity{Kind: KindWhatsApp, Value: "chat/" + m[2]})
+		}
+	}
+	if whatsAppWordRe.MatchString(text) {
+		for _, e := range findNumbers(text) {
+			if e.Kind == KindPhone {
+				entities = append(entities, Entity{Kind: KindWhatsApp, Value: e.Value})
+			}
+		}
+	}
+
+	return entities
+}
+
+// digitHomoglyphs maps letters used instead of digits to obfuscate numbers.
+var digitHomoglyphs = map[rune]rune{
+	'o': '0', 'O': '0', 'о': '0', 'О': '0',
+	'l': '1', 'I': '1', '|': '1',
+	'з': '3', 'З': '3',
+	'б': '6',
+}
+
+// numberSeparators are characters allowed between digits of a number.
+const numberSeparators = " -.()_/\u00a0"
+
+// minRealDigits is a minimum number of real digits in a number with letters used instead of digits.
+const minRealDigits = 7
+
+// findNumbers returns phones and card numbers.
+// Numbers may be split with separators and contain letters looking like digits.
+func findNumbers(text string) []Entity {
+	var entities []Entity
+	for _, candidate := range numberCandidates(text) {
+		if e, ok := parsePhone(candidate); ok {
+			entities = append(entities, e)
+		} else if e, ok := parseCard(candidate); ok {
+			entities = append(entities, e)
+		}
+	}
+
+	return entities
+}
+
+// numberCandidates returns sequences of ASCII digits with optional leading "+".
+// Text is NFKC-normalized first, so fullwidth and other compatibility digits become ASCII,
+// digits of other scripts are not a part of a number.
+func numberCandidates(text string) []string {
+	var candidates []string
+	var b strings.Builder
+	var real, separators int
+	flush := func() {
+		if real >= minRealDigits {
+			candidates = append(candidates, b.String())
+		}
+		b.Reset()
+		real, separators = 0, 0
+	}
+
+	runes := []rune(norm.NFKC.String(text))
+	for i, r := range runes {
+		switch {
+		case r >= '0' && r <= '9':
+			b.WriteRune(r)
+			real++
+			separators = 0
+		case r == '+' && b.Len() == 0:
+			b.WriteRune(r)
+		case b.Len() > 0 && isDigitHomoglyph(runes, i):
+			b.WriteRune(digitHomoglyphs[r])
+			separators = 0
+		case b.Len() > 0 && strings.ContainsRune(numberSeparators, r) && separators < 2:
+			separators++
+		default:
+			flush()
+			if r == '+' {
+				b.WriteRune(r)
+			}
+		}
+	}
+	flush()
+
+	return candidates
+}
+
+// isDigitHomoglyph reports whether letter at position i is used instead of a digit.
+// Letter is a digit only if it is not a part of a word, so "100 рублей" is not "1006".
+func isDigitHomoglyph(runes []rune, i int) bool {
+	if _, ok := digitHomoglyphs[runes[i]]; !ok {
+		return false
+	}
+	if i+1 < len(runes) && unicode.IsLetter(runes[i+1]) {
+		_, ok := digitHomoglyphs[runes[i+1]]
+		return ok
+	}
+
+	return true
+}
+
+// parsePhone parses international or Russian phone number.
+func parsePhone(candidate string) (Entity, bool) {
+	digits := strings.TrimPrefix(candidate, "+")
+	switch {
+	case strings.HasPrefix(candidate, "+") && len(digits) >= 11 && len(digits) <= 15:
+	case len(digits) == 11 && (digits[0] == '7' || digits[0] == '8') && digits[1] == '9':
+		digits = "7" + digits[1:]
+	case len(digits) == 10 && digits[0] == '9':
+		digits = "7" + digits
+	default:
+		return Entity{}, false
+	}
+
+	return Entity{Kind: KindPhone, Value: "+" + digits}, true
+}
+
+// parseCard parses payment card number validated with Luhn algorithm.
+func parseCard(candidate string) (Entity, bool) {
+	if strings.HasPrefix(candidate, "+") || len(candidate) < 16 || len(candidate) > 19 || !luhn(candidate) {
+		return Entity{}, false
+	}
+
+	// Card numbers are masked, so they are not stored in logs.
+	masked := candidate[:4] + strings.Repeat("*", len(candidate)-8) + candidate[len(candidate)-4:]
+
+	return Entity{Kind: KindCard, Value: masked}, true
+}
+
+func luhn(digits string) bool {
+	var sum int
+	for i := 0; i < len(digits); i++ {
+		d := int(digits[len(digits)-1-i] - '0')
+		if i%2 == 1 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+	}
+
+	return sum%10 == 0
+}
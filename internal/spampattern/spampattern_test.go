@@ -0,0 +1,100 @@
+package spampattern
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFind(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		text string
+		want []Entity
+	}{
+		{
+			name: "no entities",
+			text: "Цена 1000 рублей, звоните с 9 до 18, bob@example.com",
+			want: nil,
+		},
+		{
+			name: "phones",
+			text: "Звони +7 (999) 123-45-67 или 8 912 345 67 89",
+			want: []Entity{
+				{Kind: KindPhone, Value: "+79991234567"},
+				{Kind: KindPhone, Value: "+79123456789"},
+			},
+		},
+		{
+			name: "fullwidth phone",
+			text: "Звони ＋７ ９１２ ３４５ ６７ ８９",
+			want: []Entity{{Kind: KindPhone, Value: "+79123456789"}},
+		},
+		{
+			name: "other script digits",
+			text: "٨ ٩١٢ ٣٤٥ ٦٧ ٨٩",
+			want: nil,
+		},
+		{
+			name: "obfuscated phone",
+			text: "8 9оо l23 45 б7",
+			want: []Entity{{Kind: KindPhone, Value: "+79001234567"}},
+		},
+		{
+			name: "card",
+			text: "Перевод на карту 4111 1111 1111 1111, а не 4111 1111 1111 1112",
+			want: []Entity{{Kind: KindCard, Value: "4111********1111"}},
+		},
+		{
+			name: "telegram",
+			text: "Пиши в тг @Easy_Money или t.me/easymoney_bot",
+			want: []Entity{
+				{Kind: KindTelegram, Value: "@easymoney_bot"},
+				{Kind: KindTelegram, Value: "@easy_money"},
+			},
+		},
+		{
+			name: "vk mention is not telegram",
+			text: "Спасибо, @durov",
+			want: nil,
+		},
+		{
+			name: "whatsapp",
+			text: "Пишите в ватсап 89991234567 или wa.me/79001234567",
+			want: []Entity{
+				{Kind: KindWhatsApp, Value: "+79001234567"},
+				{Kind: KindWhatsApp, Value: "+79991234567"},
+				{Kind: KindPhone, Value: "+79991234567"},
+				{Kind: KindPhone, Value: "+79001234567"},
+			},
+		},
+		{
+			name: "wallets",
+			text: "BTC 1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq " +
+				"USDT TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t ETH 0xde0B295669a9FD93d5F28D9Ec85E40f4cb697BAe",
+			want: []Entity{
+				{Kind: KindWallet, Value: "1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa"},
+				{Kind: KindWallet, Value: "TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t"},
+				{Kind: KindWallet, Value: "bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq"},
+				{Kind: KindWallet, Value: "0xde0b295669a9fd93d5f28d9ec85e40f4cb697bae"},
+			},
+		},
+		{
+			name: "wallets with invalid checksum",
+			text: "1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNb bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mda",
+			want: nil,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := Find(tt.text); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Find() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package spampattern
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"math/big"
+	"regexp"
+	"strings"
+)
+
+var (
+	base58Re = regexp.MustCompile(`\b[13T][1-9A-HJ-NP-Za-km-z]{25,34}\b`)
+	bech32Re = regexp.MustCompile(`(?i)\bbc1[02-9ac-hj-np-z]{6,87}\b`)
+	// ethereumRe matches Ethereum and other EVM addresses, their checksum needs Keccak and is not validated.
+	ethereumRe = regexp.MustCompile(`\b0x[0-9a-fA-F]{40}\b`)
+)
+
+// Version bytes of base58check addresses.
+const (
+	versionBitcoinP2PKH = 0x00
+	versionBitcoinP2SH  = 0x05
+	versionTron         = 0x41
+)
+
+// findWallets returns Bitcoin, Tron and Ethereum addresses.
+// Bitcoin and Tron addresses are validated with their checksums.
+func findWallets(text string) []Entity {
+	var entities []Entity
+	for _, addr := range base58Re.FindAllString(text, -1) {
+		version, ok := decodeBase58Check(addr)
+		if !ok {
+			continue
+		}
+		if version == versionBitcoinP2PKH || version == versionBitcoinP2SH || version == versionTron {
+			entities = append(entities, Entity{Kind: KindWallet, Value: addr})
+		}
+	}
+	for _, addr := range bech32Re.FindAllString(text, -1) {
+		if verifyBech32(strings.ToLower(addr)) {
+			entities = append(entities, Entity{Kind: KindWallet, Value: strings.ToLower(addr)})
+		}
+	}
+	for _, addr := range ethereumRe.FindAllString(text, -1) {
+		entities = append(entities, Entity{Kind: KindWallet, Value: strings.ToLower(addr)})
+	}
+
+	return entities
+}
+
+const base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+// decodeBase58Check decodes base58check address and returns its version byte.
+func decodeBase58Check(addr string) (byte, bool) {
+	n := new(big.Int)
+	radix := big.NewInt(58)
+	for _, r := range addr {
+		i := strings.IndexRune(base58Alphabet, r)
+		if i < 0 {
+			return 0, false
+		}
+		n.Mul(n, radix)
+		n.Add(n, big.NewInt(int64(i)))
+	}
+
+	decoded := n.Bytes()
+	// Leading "1" characters are zero bytes.
+	for _, r := range addr {
+		if r != '1' {
+			break
+		}
+		decoded = append([]byte{0}, decoded...)
+	}
+	if len(decoded) != 25 {
+		return 0, false
+	}
+
+	payload, checksum := decoded[:21], decoded[21:]
+	first := sha256.Sum256(payload)
+	second := sha256.Sum256(first[:])
+	if !bytes.Equal(second[:4], checksum) {
+		return 0, false
+	}
+
+	return payload[0], true
+}
+
+const bech32Charset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+
+// Checksum constants of bech32 and bech32m encodings.
+const (
+	bech32Const  = 1
+	bech32mConst = 0x2bc830a3
+)
+
+// verifyBech32 verifies checksum of lowercase bech32 or bech32m address.
+func verifyBech32(addr string) bool {
+	sep := strings.LastIndexByte(addr, '1')
+	if sep < 1 || len(addr)-sep-1 < 6 {
+		return false
+	}
+
+	hrp, data := addr[:sep], addr[sep+1:]
+	values := make([]int, 0, len(hrp)*2+1+len(data))
+	for _, c := range hrp {
+		values = append(values, int(c)>>5)
+	}
+	values = append(values, 0)
+	for _, c := range hrp {
+		values = append(values, int(c)&31)
+	}
+	for _, c := range data {
+		i := strings.IndexRune(bech32Charset, c)
+		if i < 0 {
+			return false
+		}
+		values = append(values, i)
+	}
+
+	polymod := bech32Polymod(values)
+	return polymod == bech32Const || polymod == bech32mConst
+}
+
+func bech32Polymod(values []int) int {
+	generator := [5]int{0x3b6a57b2, 0x26508e6d, 0x1ea119fa, 0x3d4233dd, 0x2a1462b3}
+	chk := 1
+	for _, v := range values {
+		top := chk >> 25
+		chk = (chk&0x1ffffff)<<5 ^ v
+		for i := 0; i < 5; i++ {
+			if (top>>i)&1 == 1 {
+				chk ^= generator[i]
+			}
+		}
+	}
+
+	return chk
+}
@@ -0,0 +1,180 @@
+package textnorm
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// minSpacedLetters is a minimum number of single letters in a row which are joined into a word.
+// Shorter runs are kept, as Russian has single letter words like "я" or "в".
+const minSpacedLetters = 3
+
+// minRepeat is a minimum number of repeated characters which are collapsed into one.
+// Doubled letters are common in regular words, so they are kept.
+const minRepeat = 3
+
+// toCyrillic maps Latin letters and digits to Cyrillic letters they look like.
+var toCyrillic = map[rune]rune{
+	'a': 'а', 'b': 'в', 'c': 'с', 'e': 'е', 'h': 'н', 'k': 'к', 'm': 'м',
+	'o': 'о', 'p': 'р', 't': 'т', 'x': 'х', 'y': 'у',
+	'0': 'о', '3': 'з', '4': 'ч', '6': 'б',
+}
+
+// toLatin maps Cyrillic letters and digits to Latin letters they look like.
+var toLatin = map[rune]rune{
+	'а': 'a', 'в': 'b', 'с': 'c', 'е': 'e', 'н': 'h', 'к': 'k', 'м': 'm',
+	'о': 'o', 'р': 'p', 'т': 't', 'х': 'x', 'у': 'y', 'і': 'i',
+	'0': 'o', '1': 'i', '3': 'e', '4': 'a', '5': 's', '6': 'b', '7': 't', '8': 'b',
+}
+
+// Normalize returns lowercase words of text separated by single spaces.
+//
+// Text is normalized with NFKC, zero-width characters and combining marks are stripped,
+// homoglyphs and leetspeak digits are folded to the script of the word,
+// spaced-out letters are joined and characters repeated 3 times or more are collapsed.
+func Normalize(text string) string {
+	text = norm.NFKC.String(text)
+	text = strings.Map(func(r rune) rune {
+		if unicode.Is(unicode.Cf, r) || unicode.Is(unicode.Mn, r) {
+			return -1
+		}
+		return unicode.ToLower(r)
+	}, text)
+
+	words := joinSpaced(split(text))
+	for i, word := range words {
+		words[i] = collapseRepeats(foldConfusables(word))
+	}
+
+	return strings.Join(words, " ")
+}
+
+// token is a word with the separator before it.
+type token struct {
+	word string
+	sep  string
+}
+
+// split splits text into words of letters and digits.
+func split(text string) []token {
+	var tokens []token
+	var sep, word strings.Builder
+	for _, r := range text {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			word.WriteRune(r)
+			continue
+		}
+		if word.Len() > 0 {
+			tokens = append(tokens, token{word: word.String(), sep: sep.String()})
+			word.Reset()
+			sep.Reset()
+		}
+		sep.WriteRune(r)
+	}
+	if word.Len() > 0 {
+		tokens = append(tokens, token{word: word.String(), sep: sep.String()})
+	}
+
+	return tokens
+}
+
+// joinSpaced joins runs of single letters like "к у п и" into words.
+// Run is broken by a different separator, so "к у п и . с л о н а" is two words.
+func joinSpaced(tokens []token) []string {
+	words := make([]string, 0, len(tokens))
+	for i := 0; i < len(tokens); {
+		j := i + 1
+		for isSingleLetter(tokens[i].word) && j < len(tokens) && isSingleLetter(tokens[j].word) &&
+			(j == i+1 || tokens[j].sep == tokens[i+1].sep) {
+			j++
+		}
+		if j-i >= minSpacedLetters {
+			var b strings.Builder
+			for _, t := range tokens[i:j] {
+				b.WriteString(t.word)
+			}
+			words = append(words, b.String())
+			i = j
+			continue
+		}
+
+		words = append(words, tokens[i].word)
+		i++
+	}
+
+	return words
+}
+
+func isSingleLetter(word string) bool {
+	runes := []rune(word)
+	return len(runes) == 1 && (unicode.IsLetter(runes[0]) || unicode.IsDigit(runes[0]))
+}
+
+// foldConfusables folds homoglyphs to the script of the word.
+// The script is detected by letters which have no look-alikes in the other script,
+// or by the number of letters if both or none of such letters are present.
+// Digits are folded only in words which are mostly letters, so numbers like "5000р" are kept.
+func foldConfusables(word string) string {
+	var cyrillic, latin, digits int
+	var cyrillicOnly, latinOnly bool
+	for _, r := range word {
+		switch {
+		case unicode.Is(unicode.Cyrillic, r):
+			cyrillic++
+			_, ok := toLatin[r]
+			cyrillicOnly = cyrillicOnly || !ok
+		case unicode.Is(unicode.Latin, r):
+			latin++
+			_, ok := toCyrillic[r]
+			latinOnly = latinOnly || !ok
+		case unicode.IsDigit(r):
+			digits++
+		}
+	}
+	if cyrillic == 0 && latin == 0 {
+		return word
+	}
+
+	isCyrillic := cyrillic >= latin
+	if cyrillicOnly != latinOnly {
+		isCyrillic = cyrillicOnly
+	}
+	table := toLatin
+	if isCyrillic {
+		table = toCyrillic
+	}
+	foldDigits := cyrillic+latin > digits
+
+	return strings.Map(func(r rune) rune {
+		if unicode.IsDigit(r) && !foldDigits {
+			return r
+		}
+		if c, ok := table[r]; ok {
+			return c
+		}
+		return r
+	}, word)
+}
+
+// collapseRepeats collapses letters repeated minRepeat times or more into one.
+// Digits are kept, so numbers like "1000" are not changed.
+func collapseRepeats(word string) string {
+	runes := []rune(word)
+	result := make([]rune, 0, len(runes))
+	for i := 0; i < len(runes); {
+		j := i
+		for j < len(runes) && runes[j] == runes[i] {
+			j++
+		}
+		if j-i >= minRepeat && unicode.IsLetter(runes[i]) {
+			result = append(result, runes[i])
+		} else {
+			result = append(result, runes[i:j]...)
+		}
+		i = j
+	}
+
+	return string(result)
+}
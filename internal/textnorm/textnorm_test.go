@@ -0,0 +1,37 @@
+package textnorm
+
+import "testing"
+
+func TestNormalize(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{name: "plain text", in: "Купи слона!", want: "купи слона"},
+		{name: "fullwidth forms", in: "ＫＵＰＩ ｓｌｏｎａ", want: "kupi slona"},
+		{name: "zero-width characters", in: "ку\u200bпи сло\u00adна\ufeff", want: "купи слона"},
+		{name: "combining marks", in: "к\u0336у\u0336п\u0336и\u0336", want: "купи"},
+		{name: "latin homoglyphs in cyrillic", in: "Зapaбoтoк", want: "заработок"},
+		{name: "cyrillic homoglyphs in latin", in: "сasinо", want: "casino"},
+		{name: "leetspeak", in: "зараб0т0к и cas1n0", want: "заработок и casino"},
+		{name: "numbers are kept", in: "от 5000р в день", want: "от 5000р в день"},
+		{name: "spaced-out letters", in: "к у п и . с л о н а", want: "купи слона"},
+		{name: "short words are not joined", in: "я и ты", want: "я и ты"},
+		{name: "repeated characters", in: "купиииии слоооона", want: "купи слона"},
+		{name: "doubled letters are kept", in: "касса", want: "касса"},
+	}
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := Normalize(tt.in); got != tt.want {
+				t.Errorf("Normalize() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}